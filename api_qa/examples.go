@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gbatanov/meqa/mqswag"
+	"github.com/gbatanov/meqa/mqutil"
+	"github.com/go-openapi/spec"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// exampleMismatch is an example that doesn't validate against its schema.
+type exampleMismatch struct {
+	location string // json pointer to the example in the spec
+	errors   []string
+}
+
+// exampleValidator checks the examples in a spec against their schemas.
+type exampleValidator struct {
+	definitions spec.Definitions
+	checked     int
+	mismatches  []exampleMismatch
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// pointer builds a json pointer from unescaped tokens.
+func pointer(tokens ...string) string {
+	var b strings.Builder
+	b.WriteString("#")
+	for _, t := range tokens {
+		b.WriteString("/")
+		b.WriteString(pointerEscaper.Replace(t))
+	}
+	return b.String()
+}
+
+// validate checks the example against the schema, which is a json schema in the form of a map. The
+// spec's definitions are added to the schema so that its references resolve.
+func (v *exampleValidator) validate(location string, schema map[string]interface{}, example interface{}) {
+	v.checked++
+	if v.definitions != nil {
+		schema["definitions"] = v.definitions
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewGoLoader(schema), gojsonschema.NewGoLoader(example))
+	if err != nil {
+		v.mismatches = append(v.mismatches, exampleMismatch{location, []string{err.Error()}})
+		return
+	}
+	if result.Valid() {
+		return
+	}
+	var errors []string
+	for _, e := range result.Errors() {
+		errors = append(errors, e.String())
+	}
+	v.mismatches = append(v.mismatches, exampleMismatch{location, errors})
+}
+
+// schemaToMap returns the json form of the schema.
+func schemaToMap(schema interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{})
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+// checkSchema validates the schema's example, then the examples of the schemas nested in it.
+// References are not followed, the definitions are checked on their own.
+func (v *exampleValidator) checkSchema(location []string, schema *spec.Schema) {
+	if schema == nil {
+		return
+	}
+	if schema.Example != nil {
+		m, err := schemaToMap(schema)
+		if err != nil {
+			v.mismatches = append(v.mismatches, exampleMismatch{pointer(location...), []string{err.Error()}})
+		} else {
+			delete(m, "example")
+			v.validate(pointer(append(location, "example")...), m, schema.Example)
+		}
+	}
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop := schema.Properties[name]
+		v.checkSchema(appendTokens(location, "properties", name), &prop)
+	}
+	if schema.Items != nil {
+		v.checkSchema(appendTokens(location, "items"), schema.Items.Schema)
+	}
+	for i := range schema.AllOf {
+		v.checkSchema(appendTokens(location, "allOf", fmt.Sprint(i)), &schema.AllOf[i])
+	}
+	if schema.AdditionalProperties != nil {
+		v.checkSchema(appendTokens(location, "additionalProperties"), schema.AdditionalProperties.Schema)
+	}
+}
+
+// appendTokens returns a new slice, so that sibling locations don't share their backing array.
+func appendTokens(location []string, tokens ...string) []string {
+	return append(location[:len(location):len(location)], tokens...)
+}
+
+// checkParameter validates the parameter's example. Body parameters carry a schema; the others are
+// described by their own fields, which are close enough to a json schema once the parameter specific
+// ones are removed.
+func (v *exampleValidator) checkParameter(location []string, param *spec.Parameter) {
+	if param.In == "body" {
+		v.checkSchema(appendTokens(location, "schema"), param.Schema)
+		return
+	}
+	// x-example is the usual way to give an example for non body parameters in Swagger 2
+	example := param.Example
+	if example == nil {
+		example = param.Extensions["x-example"]
+	}
+	if example == nil {
+		return
+	}
+	m, err := schemaToMap(param)
+	if err != nil {
+		v.mismatches = append(v.mismatches, exampleMismatch{pointer(location...), []string{err.Error()}})
+		return
+	}
+	for _, field := range []string{"name", "in", "description", "required", "allowEmptyValue", "collectionFormat", "default", "example"} {
+		delete(m, field)
+	}
+	v.validate(pointer(append(location, "example")...), m, example)
+}
+
+// checkResponse validates the response's json examples against its schema, and the examples inside
+// the schema.
+func (v *exampleValidator) checkResponse(location []string, resp *spec.Response) {
+	if resp == nil || resp.Schema == nil {
+		return
+	}
+	mimeTypes := make([]string, 0, len(resp.Examples))
+	for mimeType := range resp.Examples {
+		mimeTypes = append(mimeTypes, mimeType)
+	}
+	sort.Strings(mimeTypes)
+	for _, mimeType := range mimeTypes {
+		if !strings.Contains(mimeType, "json") {
+			continue
+		}
+		m, err := schemaToMap(resp.Schema)
+		if err != nil {
+			v.mismatches = append(v.mismatches, exampleMismatch{pointer(location...), []string{err.Error()}})
+			return
+		}
+		v.validate(pointer(append(location, "examples", mimeType)...), m, resp.Examples[mimeType])
+	}
+	v.checkSchema(appendTokens(location, "schema"), resp.Schema)
+}
+
+// checkSwagger validates every example in the spec. Parameters and responses that operations refer
+// to with $ref are checked once, where the spec defines them.
+func (v *exampleValidator) checkSwagger(swagger *spec.Swagger) {
+	v.definitions = swagger.Definitions
+
+	names := make([]string, 0, len(swagger.Definitions))
+	for name := range swagger.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		schema := swagger.Definitions[name]
+		v.checkSchema([]string{"definitions", name}, &schema)
+	}
+
+	names = names[:0]
+	for name := range swagger.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		param := swagger.Parameters[name]
+		v.checkParameter([]string{"parameters", name}, &param)
+	}
+
+	names = names[:0]
+	for name := range swagger.Responses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		resp := swagger.Responses[name]
+		v.checkResponse([]string{"responses", name}, &resp)
+	}
+
+	if swagger.Paths == nil {
+		return
+	}
+	paths := make([]string, 0, len(swagger.Paths.Paths))
+	for pathName := range swagger.Paths.Paths {
+		paths = append(paths, pathName)
+	}
+	sort.Strings(paths)
+	for _, pathName := range paths {
+		pathItem := swagger.Paths.Paths[pathName]
+		for i := range pathItem.Parameters {
+			v.checkParameter([]string{"paths", pathName, "parameters", fmt.Sprint(i)}, &pathItem.Parameters[i])
+		}
+		for _, method := range mqswag.MethodAll {
+			opInterface, err := pathItem.JSONLookup(method)
+			if err != nil {
+				continue
+			}
+			op, _ := opInterface.(*spec.Operation)
+			if op == nil {
+				continue
+			}
+			location := []string{"paths", pathName, method}
+			for i := range op.Parameters {
+				v.checkParameter(appendTokens(location, "parameters", fmt.Sprint(i)), &op.Parameters[i])
+			}
+			if op.Responses == nil {
+				continue
+			}
+			v.checkResponse(appendTokens(location, "responses", "default"), op.Responses.Default)
+			codes := make([]int, 0, len(op.Responses.StatusCodeResponses))
+			for code := range op.Responses.StatusCodeResponses {
+				codes = append(codes, code)
+			}
+			sort.Ints(codes)
+			for _, code := range codes {
+				resp := op.Responses.StatusCodeResponses[code]
+				v.checkResponse(appendTokens(location, "responses", fmt.Sprint(code)), &resp)
+			}
+		}
+	}
+}
+
+// validateExamples loads the spec and reports every example that doesn't match its schema. It
+// returns the process exit code: exitFailure if any example doesn't match.
+func validateExamples(swaggerFile string) int {
+	swaggerJsonPath := swaggerFile
+	if swaggerJsonPath == stdioPath {
+		path, err := readSpecFromStdin()
+		if err != nil {
+			mqutil.Logger.Printf("Error: can't read swagger from stdin: %s", err.Error())
+			return exitUsage
+		}
+		defer os.Remove(path)
+		swaggerJsonPath = path
+	}
+	if fi, err := os.Stat(swaggerJsonPath); os.IsNotExist(err) || fi.Mode().IsDir() {
+		fmt.Printf("Can't load swagger file at the following location %s\n", swaggerJsonPath)
+		return exitUsage
+	}
+
+	// The loader needs a directory for its intermediate files
+	workPath, err := os.MkdirTemp("", "meqa-")
+	if err != nil {
+		mqutil.Logger.Printf("Error: can't create a scratch directory: %s", err.Error())
+		return exitUsage
+	}
+	defer os.RemoveAll(workPath)
+	swagger, err := mqswag.CreateSwaggerFromURL(swaggerJsonPath, workPath)
+	if err != nil {
+		mqutil.Logger.Printf("Error: %s", err.Error())
+		return exitUsage
+	}
+
+	v := &exampleValidator{}
+	v.checkSwagger((*spec.Swagger)(swagger))
+	for _, m := range v.mismatches {
+		fmt.Println(m.location)
+		for _, e := range m.errors {
+			fmt.Println("    " + e)
+		}
+	}
+	fmt.Printf("%d examples checked, %d don't match their schema\n", v.checked, len(v.mismatches))
+	if len(v.mismatches) > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
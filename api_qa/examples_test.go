@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+func swaggerFromJson(t *testing.T, s string) *spec.Swagger {
+	t.Helper()
+	swagger := &spec.Swagger{}
+	if err := json.Unmarshal([]byte(s), swagger); err != nil {
+		t.Fatalf("bad spec %s: %v", s, err)
+	}
+	return swagger
+}
+
+func TestCheckSwaggerExamples(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		checked int
+		invalid []string // locations of the mismatches, in order
+	}{
+		{"valid definition", `{"definitions":{"Pet":{"type":"object","properties":{"id":{"type":"integer"}},"example":{"id":1}}}}`,
+			1, nil},
+		{"invalid definition", `{"definitions":{"Pet":{"type":"object","properties":{"id":{"type":"integer"}},"example":{"id":"x"}}}}`,
+			1, []string{"#/definitions/Pet/example"}},
+		{"invalid property", `{"definitions":{"Pet":{"type":"object","properties":{"a/b":{"type":"string","minLength":3,"example":"xy"}}}}}`,
+			1, []string{"#/definitions/Pet/properties/a~1b/example"}},
+		{"reference into definitions",
+			`{"definitions":{"Pet":{"type":"object","required":["name"],"properties":{"name":{"type":"string"}}}},
+			  "paths":{"/pets":{"get":{"responses":{"200":{"description":"ok","schema":{"type":"array","items":{"$ref":"#/definitions/Pet"}},
+			    "examples":{"application/json":[{"name":"rex"},{"id":1}],"text/plain":"ignored"}}}}}}}`,
+			1, []string{"#/paths/~1pets/get/responses/200/examples/application~1json"}},
+		{"x-example",
+			`{"paths":{"/pets":{"parameters":[{"name":"limit","in":"query","type":"integer","maximum":10,"x-example":5}],
+			  "get":{"parameters":[{"name":"sort","in":"query","type":"string","enum":["asc","desc"],"x-example":"up"}],
+			    "responses":{"default":{"description":"error"}}}}}}`,
+			2, []string{"#/paths/~1pets/get/parameters/0/example"}},
+		{"body parameter",
+			`{"paths":{"/pets":{"post":{"parameters":[{"name":"pet","in":"body","schema":{"type":"object","required":["name"],"example":{}}}],
+			    "responses":{"201":{"description":"created"}}}}}}`,
+			1, []string{"#/paths/~1pets/post/parameters/0/schema/example"}},
+		{"shared parameters and responses",
+			`{"parameters":{"limit":{"name":"limit","in":"query","type":"integer","x-example":"ten"}},
+			  "responses":{"NotFound":{"description":"x","schema":{"type":"object","properties":{"code":{"type":"integer"}}},
+			    "examples":{"application/json":{"code":"x"}}}},
+			  "paths":{"/pets":{"get":{"parameters":[{"$ref":"#/parameters/limit"}],"responses":{"404":{"$ref":"#/responses/NotFound"}}}}}}`,
+			2, []string{"#/parameters/limit/example", "#/responses/NotFound/examples/application~1json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &exampleValidator{}
+			v.checkSwagger(swaggerFromJson(t, tt.spec))
+			var invalid []string
+			for _, m := range v.mismatches {
+				if len(m.errors) == 0 {
+					t.Errorf("mismatch at %s without errors", m.location)
+				}
+				invalid = append(invalid, m.location)
+			}
+			if v.checked != tt.checked || !reflect.DeepEqual(invalid, tt.invalid) {
+				t.Errorf("checked %d examples, mismatches at %v; want %d, %v", v.checked, invalid, tt.checked, tt.invalid)
+			}
+		})
+	}
+}
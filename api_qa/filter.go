@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gbatanov/meqa/mqswag"
+	"github.com/gbatanov/meqa/mqutil"
+	"github.com/go-openapi/spec"
+)
+
+// operationFilter decides which operations of the spec the plans are generated for.
+type operationFilter struct {
+	tags        map[string]bool // keep operations with at least one of these tags, all of them if empty
+	excludeTags map[string]bool // drop operations with any of these tags
+	includeOps  []string        // keep operations whose operationId matches one of these globs, all of them if empty
+	excludeOps  []string        // drop operations whose operationId matches any of these globs
+	methods     map[string]bool // keep operations with one of these lower case methods, all of them if empty
+	readOnly    bool            // drop operations that can modify the server, see safeMethods
+	top         int             // keep the operations with the highest DAG weight, at most this many, see topOperations
+}
+
+// The methods that don't change anything on the server
+var safeMethods = map[string]bool{mqswag.MethodGet: true, mqswag.MethodHead: true, mqswag.MethodOptions: true}
+
+// splitList splits a comma separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	var list []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) > 0 {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+func listToSet(list []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, entry := range list {
+		set[entry] = true
+	}
+	return set
+}
+
+func (f *operationFilter) isEmpty() bool {
+	return len(f.tags) == 0 && len(f.excludeTags) == 0 && len(f.includeOps) == 0 && len(f.excludeOps) == 0 &&
+		len(f.methods) == 0 && !f.readOnly
+}
+
+// checkPatterns returns an error for the first operationId glob of the filter that isn't a valid
+// pattern, so that a typo is reported instead of silently matching nothing.
+func (f *operationFilter) checkPatterns() error {
+	for _, patterns := range [][]string{f.includeOps, f.excludeOps} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return mqutil.NewError(mqutil.ErrInvalid, fmt.Sprintf("invalid operationId pattern %s: %s", pattern, err.Error()))
+			}
+		}
+	}
+	return nil
+}
+
+// matchAny returns whether the operationId matches any of the glob patterns. Operations without an
+// operationId don't match anything. The patterns are valid, see checkPatterns.
+func matchAny(patterns []string, operationId string) bool {
+	if len(operationId) == 0 {
+		return false
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, operationId); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// keep returns whether the operation for the method passes the filter.
+func (f *operationFilter) keep(method string, op *spec.Operation) bool {
+	if len(f.methods) > 0 && !f.methods[method] {
+		return false
+	}
+	if f.readOnly && !safeMethods[method] {
+		return false
+	}
+	if len(f.tags) > 0 {
+		found := false
+		for _, tag := range op.Tags {
+			if f.tags[tag] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, tag := range op.Tags {
+		if f.excludeTags[tag] {
+			return false
+		}
+	}
+	if len(f.includeOps) > 0 && !matchAny(f.includeOps, op.ID) {
+		return false
+	}
+	return !matchAny(f.excludeOps, op.ID)
+}
+
+// setOperation replaces the operation of the path item for the method.
+func setOperation(pathItem *spec.PathItem, method string, op *spec.Operation) {
+	switch method {
+	case mqswag.MethodGet:
+		pathItem.Get = op
+	case mqswag.MethodPut:
+		pathItem.Put = op
+	case mqswag.MethodPost:
+		pathItem.Post = op
+	case mqswag.MethodDelete:
+		pathItem.Delete = op
+	case mqswag.MethodHead:
+		pathItem.Head = op
+	case mqswag.MethodPatch:
+		pathItem.Patch = op
+	case mqswag.MethodOptions:
+		pathItem.Options = op
+	}
+}
+
+// filterOperations removes the operations the filter doesn't keep from the spec, and the paths that
+// are left without any operation. It returns the number of operations removed.
+func filterOperations(swagger *mqswag.Swagger, f *operationFilter) int {
+	if f.isEmpty() {
+		return 0
+	}
+	return removeOperations(swagger, func(pathName string, method string, op *spec.Operation) bool {
+		return f.keep(method, op)
+	})
+}
+
+// removeOperations removes the operations for which keep returns false from the spec, and the paths
+// that are left without any operation. It returns the number of operations removed.
+func removeOperations(swagger *mqswag.Swagger, keep func(pathName string, method string, op *spec.Operation) bool) int {
+	if swagger.Paths == nil {
+		return 0
+	}
+	removed := 0
+	for pathName, pathItem := range swagger.Paths.Paths {
+		left := 0
+		for _, method := range mqswag.MethodAll {
+			opInterface, err := pathItem.JSONLookup(method)
+			if err != nil {
+				continue
+			}
+			op, _ := opInterface.(*spec.Operation)
+			if op == nil {
+				continue
+			}
+			if keep(pathName, method, op) {
+				left++
+				continue
+			}
+			setOperation(&pathItem, method, nil)
+			removed++
+		}
+		if left == 0 {
+			delete(swagger.Paths.Paths, pathName)
+		} else {
+			swagger.Paths.Paths[pathName] = pathItem
+		}
+	}
+	return removed
+}
+
+// topOperations returns the DAG names of the n operations with the highest weight. An operation
+// weighs more the longer the chain of objects it depends on, so these are the operations that
+// exercise the most of the API. Operations of the same weight are taken in the DAG's sort order.
+func topOperations(dag *mqswag.DAG, n int) map[string]bool {
+	top := make(map[string]bool)
+	for w := mqswag.DAGDepth - 1; w >= 0 && len(top) < n; w-- {
+		for _, node := range dag.WeightList[w] {
+			if len(top) == n {
+				break
+			}
+			if node.GetType() == mqswag.TypeOp {
+				top[node.Name] = true
+			}
+		}
+	}
+	return top
+}
+
+// limitOperations removes from the spec every operation that isn't one of the n heaviest in the
+// DAG. It returns the number of operations removed, the DAG must be rebuilt if that isn't zero.
+func limitOperations(swagger *mqswag.Swagger, dag *mqswag.DAG, n int) int {
+	top := topOperations(dag, n)
+	return removeOperations(swagger, func(pathName string, method string, op *spec.Operation) bool {
+		return top[mqswag.GetDAGName(mqswag.TypeOp, pathName, method)]
+	})
+}
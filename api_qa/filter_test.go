@@ -0,0 +1,137 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gbatanov/meqa/mqswag"
+	"github.com/go-openapi/spec"
+)
+
+const filterSpec = `{
+  "paths": {
+    "/pets": {
+      "get": {"operationId": "listPets", "tags": ["pets"]},
+      "post": {"operationId": "createPet", "tags": ["pets", "write"]}
+    },
+    "/pets/{id}": {
+      "get": {"operationId": "getPet", "tags": ["pets"]},
+      "put": {"operationId": "updatePet", "tags": ["pets", "write"]},
+      "delete": {"operationId": "deletePet", "tags": ["pets", "write", "admin"]},
+      "head": {"tags": ["pets"]}
+    },
+    "/admin/stats": {
+      "get": {"operationId": "adminStats", "tags": ["admin"]},
+      "options": {"operationId": "adminOptions"}
+    }
+  }
+}`
+
+// operationsLeft lists the operations in the spec as "method path".
+func operationsLeft(swagger *mqswag.Swagger) []string {
+	var ops []string
+	for pathName, pathItem := range swagger.Paths.Paths {
+		for _, method := range mqswag.MethodAll {
+			if op, err := pathItem.JSONLookup(method); err == nil && op.(*spec.Operation) != nil {
+				ops = append(ops, method+" "+pathName)
+			}
+		}
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+func TestFilterOperations(t *testing.T) {
+	all := []string{"delete /pets/{id}", "get /admin/stats", "get /pets", "get /pets/{id}", "head /pets/{id}",
+		"options /admin/stats", "post /pets", "put /pets/{id}"}
+	tests := []struct {
+		name   string
+		filter operationFilter
+		want   []string
+	}{
+		{"empty", operationFilter{}, all},
+		{"tags", operationFilter{tags: listToSet([]string{"admin", "missing"})},
+			[]string{"delete /pets/{id}", "get /admin/stats"}},
+		{"exclude tags", operationFilter{excludeTags: listToSet([]string{"write"})},
+			[]string{"get /admin/stats", "get /pets", "get /pets/{id}", "head /pets/{id}", "options /admin/stats"}},
+		{"exclude tags win over tags", operationFilter{tags: listToSet([]string{"pets"}), excludeTags: listToSet([]string{"write"})},
+			[]string{"get /pets", "get /pets/{id}", "head /pets/{id}"}},
+		{"include ops", operationFilter{includeOps: []string{"*Pet", "admin?tats"}},
+			[]string{"delete /pets/{id}", "get /admin/stats", "get /pets/{id}", "post /pets", "put /pets/{id}"}},
+		{"exclude ops", operationFilter{excludeOps: []string{"*Pet*"}},
+			[]string{"get /admin/stats", "head /pets/{id}", "options /admin/stats"}},
+		{"exclude ops win over include ops", operationFilter{includeOps: []string{"*Pet"}, excludeOps: []string{"delete*", "update*"}},
+			[]string{"get /pets/{id}", "post /pets"}},
+		{"include ops drop operations without an id", operationFilter{includeOps: []string{"*"}},
+			[]string{"delete /pets/{id}", "get /admin/stats", "get /pets", "get /pets/{id}", "options /admin/stats", "post /pets", "put /pets/{id}"}},
+		{"methods", operationFilter{methods: listToSet([]string{"get", "delete"})},
+			[]string{"delete /pets/{id}", "get /admin/stats", "get /pets", "get /pets/{id}"}},
+		{"read only", operationFilter{readOnly: true},
+			[]string{"get /admin/stats", "get /pets", "get /pets/{id}", "head /pets/{id}", "options /admin/stats"}},
+		{"read only and methods", operationFilter{readOnly: true, methods: listToSet([]string{"get", "post"})},
+			[]string{"get /admin/stats", "get /pets", "get /pets/{id}"}},
+		{"read only with only unsafe methods", operationFilter{readOnly: true, methods: listToSet([]string{"post"})}, nil},
+		{"all filters", operationFilter{tags: listToSet([]string{"pets"}), excludeTags: listToSet([]string{"admin"}),
+			includeOps: []string{"*Pet*"}, excludeOps: []string{"list*"}, readOnly: true},
+			[]string{"get /pets/{id}"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			swagger := (*mqswag.Swagger)(swaggerFromJson(t, filterSpec))
+			removed := filterOperations(swagger, &tt.filter)
+			got := operationsLeft(swagger)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("operations left %v, want %v", got, tt.want)
+			}
+			if removed != len(all)-len(tt.want) {
+				t.Errorf("%d operations removed, want %d", removed, len(all)-len(tt.want))
+			}
+			// Paths without operations are removed
+			for pathName, pathItem := range swagger.Paths.Paths {
+				if pathItem.Get == nil && pathItem.Put == nil && pathItem.Post == nil && pathItem.Delete == nil &&
+					pathItem.Head == nil && pathItem.Patch == nil && pathItem.Options == nil {
+					t.Errorf("path %s left without operations", pathName)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterPathsRemoved(t *testing.T) {
+	swagger := (*mqswag.Swagger)(swaggerFromJson(t, filterSpec))
+	filterOperations(swagger, &operationFilter{tags: listToSet([]string{"admin"}), methods: listToSet([]string{"get"})})
+	if _, ok := swagger.Paths.Paths["/pets"]; ok {
+		t.Error("/pets wasn't removed")
+	}
+	if pathItem := swagger.Paths.Paths["/admin/stats"]; pathItem.Get == nil || pathItem.Options != nil {
+		t.Errorf("/admin/stats wasn't filtered: %v", pathItem)
+	}
+}
+
+func TestCheckPatterns(t *testing.T) {
+	tests := []struct {
+		filter operationFilter
+		valid  bool
+	}{
+		{operationFilter{}, true},
+		{operationFilter{includeOps: []string{"get*", "list?ets", "[a-z]*"}, excludeOps: []string{`\*`}}, true},
+		{operationFilter{includeOps: []string{"get*", "get["}}, false},
+		{operationFilter{excludeOps: []string{"ok", `pet\`}}, false},
+		{operationFilter{excludeOps: []string{"[^"}}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.filter.checkPatterns(); (err == nil) != tt.valid {
+			t.Errorf("checkPatterns(%v, %v) = %v", tt.filter.includeOps, tt.filter.excludeOps, err)
+		}
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	if got, want := splitList(" a, b,,c ,"), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("splitList = %v, want %v", got, want)
+	}
+	if got := splitList(""); got != nil {
+		t.Errorf("splitList of an empty value = %v", got)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/gbatanov/meqa/mqplan"
+	"github.com/gbatanov/meqa/mqswag"
+	"github.com/gbatanov/meqa/mqutil"
+	"github.com/go-openapi/spec"
+)
+
+// generatePlan runs the generator for a single algorithm.
+func generatePlan(algo string, swagger *mqswag.Swagger, dag *mqswag.DAG, whitelist map[string]bool) (*mqplan.TestPlan, error) {
+	switch algo {
+	case algoPath:
+		return mqplan.GeneratePathTestPlan(swagger, dag, whitelist)
+	case algoObject:
+		return mqplan.GenerateTestPlan(swagger, dag)
+	default:
+		return mqplan.GenerateSimpleTestPlan(swagger, dag)
+	}
+}
+
+// generatePlans generates one plan per algorithm, in the same order as algos. When there is more
+// than one algorithm the generators run concurrently. The DAG isn't safe for concurrent use, so
+// every generator gets its own copy of the spec and its own DAG built from that copy.
+func generatePlans(swagger *mqswag.Swagger, dag *mqswag.DAG, algos []string, whitelist map[string]bool) ([]*mqplan.TestPlan, error) {
+	plans := make([]*mqplan.TestPlan, len(algos))
+	if len(algos) == 1 {
+		plan, err := generatePlan(algos[0], swagger, dag, whitelist)
+		plans[0] = plan
+		return plans, err
+	}
+
+	swaggerBytes, err := json.Marshal((*spec.Swagger)(swagger))
+	if err != nil {
+		return nil, err
+	}
+	errs := make([]error, len(algos))
+	var wg sync.WaitGroup
+	for i, algo := range algos {
+		wg.Add(1)
+		go func(i int, algo string) {
+			defer wg.Done()
+			swaggerCopy, dagCopy, err := newSwaggerAndDAG(swaggerBytes)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			plans[i], errs[i] = generatePlan(algo, swaggerCopy, dagCopy, whitelist)
+		}(i, algo)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return plans, nil
+}
+
+// newSwaggerAndDAG creates a private copy of the spec from its json form, and a sorted and checked
+// DAG for it.
+func newSwaggerAndDAG(swaggerBytes []byte) (*mqswag.Swagger, *mqswag.DAG, error) {
+	specCopy := &spec.Swagger{}
+	err := json.Unmarshal(swaggerBytes, specCopy)
+	if err != nil {
+		return nil, nil, err
+	}
+	swagger := (*mqswag.Swagger)(specCopy)
+	dag, err := newDAG(swagger)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = checkWeights(dag)
+	if err != nil {
+		return nil, nil, err
+	}
+	return swagger, dag, nil
+}
+
+// newDAG creates the DAG for the spec and sorts it.
+func newDAG(swagger *mqswag.Swagger) (*mqswag.DAG, error) {
+	dag := mqswag.NewDAG()
+	err := swagger.AddToDAG(dag)
+	if err != nil {
+		return nil, err
+	}
+	dag.Sort()
+	return dag, nil
+}
+
+// checkWeights does the check of DAG.CheckWeight, that every node weighs less than its children,
+// without printing the DAG in verbose mode. The copies of the DAG are checked with it, so that the
+// concurrent generators don't print the same DAG over each other.
+func checkWeights(dag *mqswag.DAG) error {
+	for w := range dag.WeightList {
+		for _, node := range dag.WeightList[w] {
+			for _, child := range node.Children {
+				if child.Weight <= node.Weight {
+					return mqutil.NewError(mqutil.ErrInvalid, fmt.Sprintf("bad weight detected: %s weighs %d, its child %s weighs %d",
+						node.Name, node.Weight, child.Name, child.Weight))
+				}
+			}
+		}
+	}
+	return nil
+}
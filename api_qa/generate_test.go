@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gbatanov/meqa/mqswag"
+	"github.com/gbatanov/meqa/mqutil"
+)
+
+const testSpec = `{
+  "swagger": "2.0",
+  "info": {"title": "pets", "version": "1.0"},
+  "basePath": "/v1",
+  "paths": {
+    "/pets": {
+      "get": {
+        "operationId": "listPets",
+        "responses": {"200": {"description": "ok", "schema": {"type": "array", "items": {"$ref": "#/definitions/Pet"}}}}
+      },
+      "post": {
+        "operationId": "createPet",
+        "parameters": [{"name": "pet", "in": "body", "required": true, "schema": {"$ref": "#/definitions/Pet"}}],
+        "responses": {"201": {"description": "created", "schema": {"$ref": "#/definitions/Pet"}}}
+      }
+    },
+    "/pets/{id}": {
+      "get": {
+        "operationId": "getPet",
+        "parameters": [{"name": "id", "in": "path", "required": true, "type": "integer"}],
+        "responses": {"200": {"description": "ok", "schema": {"$ref": "#/definitions/Pet"}}}
+      },
+      "delete": {
+        "operationId": "deletePet",
+        "parameters": [{"name": "id", "in": "path", "required": true, "type": "integer"}],
+        "responses": {"204": {"description": "deleted"}}
+      }
+    }
+  },
+  "definitions": {
+    "Pet": {
+      "type": "object",
+      "required": ["name"],
+      "properties": {"id": {"type": "integer"}, "name": {"type": "string"}}
+    }
+  }
+}`
+
+// TestGeneratePlansConcurrently generates the plans of every algorithm at once. Run it with -race:
+// the generators must not share the spec or the DAG.
+func TestGeneratePlansConcurrently(t *testing.T) {
+	mqutil.Logger = mqutil.NewStdLogger()
+	dir := t.TempDir()
+	specPath := filepath.Join(dir, "swagger.json")
+	if err := os.WriteFile(specPath, []byte(testSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	swagger, err := mqswag.CreateSwaggerFromURL(specPath, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dag, err := newDAG(swagger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plans, err := generatePlans(swagger, dag, algoList, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != len(algoList) {
+		t.Fatalf("got %d plans for %d algorithms", len(plans), len(algoList))
+	}
+	for i, plan := range plans {
+		if plan == nil {
+			t.Errorf("no %s plan", algoList[i])
+		}
+	}
+}
+
+func TestCheckWeights(t *testing.T) {
+	dag := mqswag.NewDAG()
+	parent := &mqswag.DAGNode{Name: mqswag.GetDAGName(mqswag.TypeDef, "Pet", ""), Weight: 1}
+	child := &mqswag.DAGNode{Name: mqswag.GetDAGName(mqswag.TypeOp, "/pets", mqswag.MethodPost), Weight: 2}
+	parent.Children = mqswag.NodeList{child}
+	dag.WeightList[1] = mqswag.NodeList{parent}
+	dag.WeightList[2] = mqswag.NodeList{child}
+	if err := checkWeights(dag); err != nil {
+		t.Errorf("checkWeights rejected a valid DAG: %v", err)
+	}
+
+	child.Weight = 1
+	dag.WeightList[1] = mqswag.NodeList{parent, child}
+	dag.WeightList[2] = nil
+	if err := checkWeights(dag); err == nil {
+		t.Error("checkWeights accepted a child that doesn't weigh more than its parent")
+	}
+}
@@ -7,12 +7,13 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
-	"github.com/gbatanov/meqa/mqplan"
 	"github.com/gbatanov/meqa/mqswag"
 	"github.com/gbatanov/meqa/mqutil"
+	apiutil "github.com/mmanjoura/vmie-api-qa/api_util"
 )
 
 // Constants for the algorithm types
@@ -24,9 +25,25 @@ const (
 	algoAll     = "all"
 )
 
+// Modes of operation
+const (
+	modeGenerate         = "generate"          // generate the test plans
+	modeValidateExamples = "validate-examples" // check the spec's examples against their schemas
+)
+
 // List of available algorithms
 var algoList []string = []string{algoSimple, algoObject, algoPath}
 
+// Where diagnostics that aren't logged are printed. Stderr when the plans are written to stdout.
+var diagOut io.Writer = os.Stdout
+
+// Exit codes, so that CI can tell bad invocations apart from generation failures
+const (
+	exitOK      = 0 // all requested plans were generated
+	exitFailure = 1 // the spec was loaded but plan generation failed
+	exitUsage   = 2 // bad flags or unreadable input files
+)
+
 func main() {
 	// Set up logger
 	mqutil.Logger = mqutil.NewStdLogger()
@@ -35,29 +52,124 @@ func main() {
 	swaggerJSONFile := filepath.Join(meqaDataDir, "swagger.yml")
 
 	// Define command-line flags
-	meqaPath := flag.String("d", meqaDataDir, "the directory where we put the generated files")
-	swaggerFile := flag.String("s", swaggerJSONFile, "the swagger.yml file location")
+	mode := flag.String("mode", modeGenerate, "what to do - generate, validate-examples")
+	meqaPath := flag.String("d", meqaDataDir, "the directory where we put the generated files, - for stdout")
+	swaggerFile := flag.String("s", swaggerJSONFile, "the swagger.yml file location, - for stdin")
 	algorithm := flag.String("a", "all", "the algorithm - simple, object, path, all")
 	verbose := flag.Bool("v", false, "turn on verbose mode")
 	whitelistFile := flag.String("w", "", "the whitelist.txt file location")
+	watch := flag.Bool("watch", false, "keep running and regenerate the plans whenever the swagger file changes")
+	tags := flag.String("tags", "", "only generate tests for operations with one of these comma separated tags")
+	excludeTags := flag.String("exclude-tags", "", "don't generate tests for operations with any of these comma separated tags")
+	includeOps := flag.String("include-ops", "", "only generate tests for operations whose operationId matches one of these comma separated globs")
+	excludeOps := flag.String("exclude-ops", "", "don't generate tests for operations whose operationId matches any of these comma separated globs")
+	readOnly := flag.Bool("read-only", false, "only generate tests for GET, HEAD and OPTIONS operations, so the plans are safe to run against production")
+	top := flag.Int("top", 0, "only generate tests for this many operations, the ones with the highest DAG weight, 0 for all of them")
+	presetName := flag.String("preset", "", "the preset to use - smoke, regression, full or one from the presets file")
+	presetsPath := flag.String("presets", "", "the presets file location, defaults to presets.yml in the meqa directory")
 
 	// Parse command-line flags
 	flag.Parse()
 
+	filter := &operationFilter{
+		tags:        listToSet(splitList(*tags)),
+		excludeTags: listToSet(splitList(*excludeTags)),
+		includeOps:  splitList(*includeOps),
+		excludeOps:  splitList(*excludeOps),
+		top:         *top,
+	}
+
+	// Apply the preset, the flags given explicitly win over it
+	config := &manifestConfig{}
+	if len(*presetName) > 0 {
+		path, required := *presetsPath, true
+		if len(path) == 0 {
+			path, required = filepath.Join(*meqaPath, presetsFile), false
+		}
+		presets, err := loadPresets(path, required)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Can't load the presets: %s\n", err.Error())
+			os.Exit(exitUsage)
+		}
+		p, ok := presets[*presetName]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown preset %s, available presets: %s\n", *presetName, presetNames(presets))
+			os.Exit(exitUsage)
+		}
+		setFlags := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+		p.apply(setFlags, algorithm, filter)
+		config.Preset = *presetName
+		if p != builtinPresets[*presetName] {
+			config.PresetsFile = path
+			config.PresetsSHA256 = fileSHA256(path)
+		}
+	}
+	if *readOnly {
+		filter.readOnly = true
+	}
+	if err := filter.checkPatterns(); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitUsage)
+	}
+	config.resolve(*algorithm, *whitelistFile, filter)
+
 	// Run the program with the provided options
-	run(meqaPath, swaggerFile, algorithm, verbose, whitelistFile)
+	switch *mode {
+	case modeGenerate:
+	case modeValidateExamples:
+		os.Exit(validateExamples(*swaggerFile))
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown mode %s\n", *mode)
+		os.Exit(exitUsage)
+	}
+
+	// Keep stdout clean for the plans when they are written there. Otherwise the log also goes to a
+	// file of its own for this run, under the meqa directory. Only generation gets a run log, the
+	// other modes don't write to the meqa directory.
+	if *meqaPath == stdioPath {
+		// The meqa packages print to stdout in verbose mode, and watch mode prints its reports there
+		if *verbose || *watch {
+			fmt.Fprintln(os.Stderr, "Can't use -v or -watch when writing the plans to stdout")
+			os.Exit(exitUsage)
+		}
+		mqutil.Logger = mqutil.NewLogger(os.Stderr)
+		diagOut = os.Stderr
+	} else if runLog, err := apiutil.NewRunLogFile(*meqaPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Can't create the run log file: %s\n", err.Error())
+	} else {
+		// Writes aren't buffered, so the file needs no closing before os.Exit
+		mqutil.Logger = mqutil.NewLogger(io.MultiWriter(os.Stdout, runLog))
+	}
+	if *watch {
+		if *swaggerFile == stdioPath {
+			fmt.Fprintln(os.Stderr, "Can't watch a swagger file read from stdin")
+			os.Exit(exitUsage)
+		}
+		os.Exit(watchAndRun(meqaPath, swaggerFile, algorithm, verbose, whitelistFile, filter, config))
+	}
+	os.Exit(run(meqaPath, swaggerFile, algorithm, verbose, whitelistFile, filter, config))
 }
 
-// Function to run the program with the provided options
-func run(meqaPath *string, swaggerFile *string, algorithm *string, verbose *bool, whitelistFile *string) {
+// Function to run the program with the provided options. It returns the process exit code.
+func run(meqaPath *string, swaggerFile *string, algorithm *string, verbose *bool, whitelistFile *string, filter *operationFilter, config *manifestConfig) (exitCode int) {
 	// Set verbose mode
 	mqutil.Verbose = *verbose
 
 	// Validate swagger file path
 	swaggerJsonPath := *swaggerFile
+	if swaggerJsonPath == stdioPath {
+		path, err := readSpecFromStdin()
+		if err != nil {
+			mqutil.Logger.Printf("Error: can't read swagger from stdin: %s", err.Error())
+			return exitUsage
+		}
+		defer os.Remove(path)
+		swaggerJsonPath = path
+	}
 	if fi, err := os.Stat(swaggerJsonPath); os.IsNotExist(err) || fi.Mode().IsDir() {
-		fmt.Printf("Can't load swagger file at the following location %s", swaggerJsonPath)
-		os.Exit(1)
+		fmt.Fprintf(diagOut, "Can't load swagger file at the following location %s\n", swaggerJsonPath)
+		return exitUsage
 	}
 
 	// Validate whitelist file path
@@ -65,47 +177,86 @@ func run(meqaPath *string, swaggerFile *string, algorithm *string, verbose *bool
 	var whitelist map[string]bool
 	if len(whitelistPath) > 0 {
 		if fi, err := os.Stat(whitelistPath); os.IsNotExist(err) || fi.Mode().IsDir() {
-			fmt.Printf("Can't load whitelist file at the following location %s", whitelistPath)
-			os.Exit(1)
+			fmt.Fprintf(diagOut, "Can't load whitelist file at the following location %s\n", whitelistPath)
+			return exitUsage
 		}
 		wl, err := mqswag.GetWhitelistSuites(whitelistPath)
 		whitelist = wl
 		if err != nil {
 			mqutil.Logger.Printf("Error: %s", err.Error())
-			os.Exit(1)
+			return exitUsage
 		}
 	}
 
-	// Validate test plan directory path
+	// Validate test plan directory path. When writing to stdout nothing is kept on disk, a
+	// scratch directory holds the intermediate files.
 	testPlanPath := *meqaPath
-	if fi, err := os.Stat(testPlanPath); os.IsNotExist(err) {
+	toStdout := testPlanPath == stdioPath
+	if toStdout {
+		workPath, err := os.MkdirTemp("", "meqa-")
+		if err != nil {
+			mqutil.Logger.Printf("Error: can't create a scratch directory: %s", err.Error())
+			return exitUsage
+		}
+		defer os.RemoveAll(workPath)
+		testPlanPath = workPath
+	} else if fi, err := os.Stat(testPlanPath); os.IsNotExist(err) {
 		err = os.Mkdir(testPlanPath, 0755)
 		if err != nil {
 			fmt.Printf("Can't create the directory at %s\n", testPlanPath)
-			os.Exit(1)
+			return exitUsage
 		}
 	} else if !fi.Mode().IsDir() {
 		fmt.Printf("The specified location is not a directory: %s\n", testPlanPath)
-		os.Exit(1)
+		return exitUsage
+	}
+
+	// Record the run next to the plans
+	manifest := newRunManifest(*swaggerFile, swaggerJsonPath, config)
+	if !toStdout {
+		defer func() {
+			if err := manifest.write(testPlanPath, exitCode); err != nil {
+				mqutil.Logger.Printf("Error: can't write the run manifest: %s", err.Error())
+			}
+		}()
 	}
 
 	// Load swagger.json
-	swagger, err := mqswag.CreateSwaggerFromURL(swaggerJsonPath, *meqaPath)
+	swagger, err := mqswag.CreateSwaggerFromURL(swaggerJsonPath, testPlanPath)
 	if err != nil {
 		mqutil.Logger.Printf("Error: %s", err.Error())
-		os.Exit(1)
+		return exitUsage
+	}
+
+	// Drop the operations that are filtered out, before they get into the DAG
+	removed := filterOperations(swagger, filter)
+	if removed > 0 && *verbose {
+		fmt.Fprintf(diagOut, "%d operations filtered out\n", removed)
 	}
 
-	// Create and populate DAG (Directed Acyclic Graph)
-	dag := mqswag.NewDAG()
-	err = swagger.AddToDAG(dag)
+	// Create, populate and sort the DAG (Directed Acyclic Graph)
+	dag, err := newDAG(swagger)
 	if err != nil {
 		mqutil.Logger.Printf("Error: %s", err.Error())
-		os.Exit(1)
+		return exitFailure
 	}
 
-	// Sort and check weight of DAG
-	dag.Sort()
+	// Keep only the heaviest operations, the weights are only known once the DAG is built
+	if filter.top > 0 {
+		removed := limitOperations(swagger, dag, filter.top)
+		if removed > 0 {
+			if *verbose {
+				fmt.Fprintf(diagOut, "%d operations beyond the top %d filtered out\n", removed, filter.top)
+			}
+			dag, err = newDAG(swagger)
+			if err != nil {
+				mqutil.Logger.Printf("Error: %s", err.Error())
+				return exitFailure
+			}
+		}
+	}
+
+	// Check weight of DAG, in verbose mode this also prints it
 	dag.CheckWeight()
 
 	// Generate test plans based on selected algorithms
@@ -115,28 +266,32 @@ func run(meqaPath *string, swaggerFile *string, algorithm *string, verbose *bool
 	} else {
 		plansToGenerate = append(plansToGenerate, *algorithm)
 	}
+	manifest.Algorithms = plansToGenerate
 
-	for _, algo := range plansToGenerate {
-		var testPlan *mqplan.TestPlan
-		switch algo {
-		case algoPath:
-			testPlan, err = mqplan.GeneratePathTestPlan(swagger, dag, whitelist)
-		case algoObject:
-			testPlan, err = mqplan.GenerateTestPlan(swagger, dag)
-		default:
-			testPlan, err = mqplan.GenerateSimpleTestPlan(swagger, dag)
-		}
-		if err != nil {
-			mqutil.Logger.Printf("Error: %s", err.Error())
-			os.Exit(1)
+	testPlans, err := generatePlans(swagger, dag, plansToGenerate, whitelist)
+	if err != nil {
+		mqutil.Logger.Printf("Error: %s", err.Error())
+		return exitFailure
+	}
+
+	for i, algo := range plansToGenerate {
+		testPlan := testPlans[i]
+		if toStdout {
+			err = dumpPlanToStdout(testPlan, algo, testPlanPath)
+			if err != nil {
+				mqutil.Logger.Printf("Error: %s", err.Error())
+				return exitFailure
+			}
+			continue
 		}
 		testPlanFile := filepath.Join(testPlanPath, algo+".yml")
 		err = testPlan.DumpToFile(testPlanFile)
 		if err != nil {
 			mqutil.Logger.Printf("Error: %s", err.Error())
-			os.Exit(1)
+			return exitFailure
 		}
+		manifest.addPlan(algo, testPlanFile)
 		fmt.Println("Test plans generated at:", testPlanFile)
 	}
+	return exitOK
 }
-
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"time"
+)
+
+// The manifest written next to the plans of every run
+const manifestFile = "run.json"
+
+// manifestPlan describes a plan file written by the run.
+type manifestPlan struct {
+	Algorithm string `json:"algorithm"`
+	File      string `json:"file"`
+	SHA256    string `json:"sha256,omitempty"`
+}
+
+// manifestConfig is the configuration a run used, once the preset and the flags are resolved. The
+// arguments alone don't tell what a preset from the presets file did.
+type manifestConfig struct {
+	Preset          string   `json:"preset,omitempty"`
+	PresetsFile     string   `json:"presetsFile,omitempty"` // where the preset was defined, empty for a built-in one
+	PresetsSHA256   string   `json:"presetsSha256,omitempty"`
+	Algorithm       string   `json:"algorithm"`
+	Whitelist       string   `json:"whitelist,omitempty"`
+	WhitelistSHA256 string   `json:"whitelistSha256,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	ExcludeTags     []string `json:"excludeTags,omitempty"`
+	IncludeOps      []string `json:"includeOps,omitempty"`
+	ExcludeOps      []string `json:"excludeOps,omitempty"`
+	Methods         []string `json:"methods,omitempty"`
+	ReadOnly        bool     `json:"readOnly,omitempty"`
+	Top             int      `json:"top,omitempty"`
+}
+
+// setToList returns the entries of the set in order.
+func setToList(set map[string]bool) []string {
+	var list []string
+	for entry := range set {
+		list = append(list, entry)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// resolve records the settings that are in effect.
+func (c *manifestConfig) resolve(algorithm string, whitelistFile string, filter *operationFilter) {
+	c.Algorithm = algorithm
+	if len(whitelistFile) > 0 {
+		c.Whitelist = whitelistFile
+		c.WhitelistSHA256 = fileSHA256(whitelistFile)
+	}
+	c.Tags = setToList(filter.tags)
+	c.ExcludeTags = setToList(filter.excludeTags)
+	c.IncludeOps = filter.includeOps
+	c.ExcludeOps = filter.excludeOps
+	c.Methods = setToList(filter.methods)
+	c.ReadOnly = filter.readOnly
+	c.Top = filter.top
+}
+
+// runManifest records what a run did and with what inputs, so that its output can be reproduced and
+// runs on the same spec can be recognized.
+type runManifest struct {
+	Version    string          `json:"version"`
+	Revision   string          `json:"revision,omitempty"` // vcs revision the tool was built from
+	GoVersion  string          `json:"goVersion"`
+	OS         string          `json:"os"`
+	Arch       string          `json:"arch"`
+	Args       []string        `json:"args"`
+	SpecFile   string          `json:"specFile"`
+	SpecSHA256 string          `json:"specSha256,omitempty"`
+	Config     *manifestConfig `json:"config,omitempty"`
+	Algorithms []string        `json:"algorithms,omitempty"`
+	Start      time.Time       `json:"start"`
+	End        time.Time       `json:"end"`
+	ExitCode   int             `json:"exitCode"`
+	Plans      []manifestPlan  `json:"plans"`
+}
+
+// fileSHA256 returns the hex encoded hash of the file's content, or "" if it can't be read.
+func fileSHA256(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newRunManifest starts the manifest of a run on the spec with the configuration. specFile is the name
+// given on the command line, specPath the file actually read.
+func newRunManifest(specFile string, specPath string, config *manifestConfig) *runManifest {
+	m := &runManifest{
+		Version:    "(devel)",
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Args:       os.Args[1:],
+		SpecFile:   specFile,
+		SpecSHA256: fileSHA256(specPath),
+		Config:     config,
+		Start:      time.Now(),
+		Plans:      []manifestPlan{},
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if len(info.Main.Version) > 0 {
+			m.Version = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				m.Revision = s.Value
+			}
+		}
+	}
+	return m
+}
+
+// addPlan records a plan file written by the run.
+func (m *runManifest) addPlan(algorithm string, file string) {
+	m.Plans = append(m.Plans, manifestPlan{algorithm, filepath.Base(file), fileSHA256(file)})
+}
+
+// write finishes the manifest with the exit code and writes it to the directory.
+func (m *runManifest) write(dir string, exitCode int) error {
+	m.End = time.Now()
+	m.ExitCode = exitCode
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, manifestFile), append(b, '\n'), 0644)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestManifestConfig(t *testing.T) {
+	dir := t.TempDir()
+	whitelist := filepath.Join(dir, "whitelist.txt")
+	if err := os.WriteFile(whitelist, []byte("suite\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := &manifestConfig{Preset: "nightly", PresetsFile: "presets.yml", PresetsSHA256: "abc"}
+	config.resolve(algoObject, whitelist, &operationFilter{tags: listToSet([]string{"b", "a"}), excludeOps: []string{"del*"},
+		methods: listToSet([]string{"get"}), readOnly: true, top: 10})
+	want := &manifestConfig{Preset: "nightly", PresetsFile: "presets.yml", PresetsSHA256: "abc", Algorithm: algoObject,
+		Whitelist: whitelist, WhitelistSHA256: fileSHA256(whitelist), Tags: []string{"a", "b"}, ExcludeOps: []string{"del*"},
+		Methods: []string{"get"}, ReadOnly: true, Top: 10}
+	if !reflect.DeepEqual(config, want) || len(config.WhitelistSHA256) != 64 {
+		t.Fatalf("config %+v\nwant %+v", config, want)
+	}
+
+	specPath := filepath.Join(dir, "swagger.json")
+	if err := os.WriteFile(specPath, []byte(testSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := newRunManifest("-", specPath, config)
+	if err := m.write(dir, exitFailure); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written runManifest
+	if err := json.Unmarshal(b, &written); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(written.Config, config) || written.ExitCode != exitFailure || written.SpecSHA256 != fileSHA256(specPath) {
+		t.Errorf("manifest written as %s", b)
+	}
+}
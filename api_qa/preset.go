@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gbatanov/meqa/mqutil"
+	"gopkg.in/yaml.v3"
+)
+
+// preset is a named set of generation settings, so that teams can share run profiles.
+type preset struct {
+	Algorithm   string   `yaml:"algorithm"`
+	Tags        []string `yaml:"tags"`
+	ExcludeTags []string `yaml:"excludeTags"`
+	IncludeOps  []string `yaml:"includeOps"`
+	ExcludeOps  []string `yaml:"excludeOps"`
+	Methods     []string `yaml:"methods"`
+	ReadOnly    bool     `yaml:"readOnly"`
+	Top         int      `yaml:"top"`
+}
+
+// The presets file looked up in the meqa data directory
+const presetsFile = "presets.yml"
+
+// builtinPresets are always available. The presets file can redefine them.
+var builtinPresets = map[string]*preset{
+	"smoke":      {Algorithm: algoSimple, Methods: []string{"get"}, Top: 20},
+	"regression": {Algorithm: algoObject},
+	"full":       {Algorithm: algoAll},
+}
+
+// loadPresets returns the built-in presets together with the ones defined in the file, which is a
+// map from the preset name to its settings. A missing file is only an error if required is set.
+func loadPresets(path string, required bool) (map[string]*preset, error) {
+	presets := make(map[string]*preset)
+	for name, p := range builtinPresets {
+		presets[name] = p
+	}
+	presetBytes, err := os.ReadFile(path)
+	if os.IsNotExist(err) && !required {
+		return presets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var filePresets map[string]*preset
+	err = yaml.Unmarshal(presetBytes, &filePresets)
+	if err != nil {
+		return nil, mqutil.NewError(mqutil.ErrInvalid, fmt.Sprintf("invalid presets in %s: %s", path, err.Error()))
+	}
+	for name, p := range filePresets {
+		if p == nil {
+			p = &preset{}
+		}
+		presets[name] = p
+	}
+	return presets, nil
+}
+
+// presetNames returns the names of the presets in order, for error messages.
+func presetNames(presets map[string]*preset) string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// apply sets the algorithm and the filter from the preset. Settings given on the command line,
+// listed in setFlags, take precedence over the preset.
+func (p *preset) apply(setFlags map[string]bool, algorithm *string, filter *operationFilter) {
+	if len(p.Algorithm) > 0 && !setFlags["a"] {
+		*algorithm = p.Algorithm
+	}
+	if len(p.Tags) > 0 && !setFlags["tags"] {
+		filter.tags = listToSet(p.Tags)
+	}
+	if len(p.ExcludeTags) > 0 && !setFlags["exclude-tags"] {
+		filter.excludeTags = listToSet(p.ExcludeTags)
+	}
+	if len(p.IncludeOps) > 0 && !setFlags["include-ops"] {
+		filter.includeOps = p.IncludeOps
+	}
+	if len(p.ExcludeOps) > 0 && !setFlags["exclude-ops"] {
+		filter.excludeOps = p.ExcludeOps
+	}
+	if len(p.Methods) > 0 {
+		filter.methods = make(map[string]bool)
+		for _, method := range p.Methods {
+			filter.methods[strings.ToLower(method)] = true
+		}
+	}
+	if p.ReadOnly {
+		filter.readOnly = true
+	}
+	if p.Top > 0 && !setFlags["top"] {
+		filter.top = p.Top
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPresetApply(t *testing.T) {
+	p := &preset{Algorithm: algoSimple, Tags: []string{"pets"}, ExcludeTags: []string{"slow"}, IncludeOps: []string{"get*"},
+		ExcludeOps: []string{"*Admin"}, Methods: []string{"GET", "head"}, ReadOnly: true, Top: 20}
+
+	// Nothing given on the command line, the preset sets everything
+	algorithm, filter := algoAll, &operationFilter{}
+	p.apply(map[string]bool{}, &algorithm, filter)
+	want := &operationFilter{tags: listToSet([]string{"pets"}), excludeTags: listToSet([]string{"slow"}), includeOps: []string{"get*"},
+		excludeOps: []string{"*Admin"}, methods: listToSet([]string{"get", "head"}), readOnly: true, top: 20}
+	if algorithm != algoSimple || !reflect.DeepEqual(filter, want) {
+		t.Errorf("apply set %s, %+v; want %s, %+v", algorithm, filter, algoSimple, want)
+	}
+
+	// The flags given explicitly win, even when they are set to their default
+	algorithm = algoObject
+	filter = &operationFilter{tags: listToSet([]string{"admin"}), top: 5}
+	p.apply(map[string]bool{"a": true, "tags": true, "top": true}, &algorithm, filter)
+	if algorithm != algoObject {
+		t.Errorf("explicit -a overridden by the preset: %s", algorithm)
+	}
+	if !reflect.DeepEqual(filter.tags, listToSet([]string{"admin"})) {
+		t.Errorf("explicit -tags overridden by the preset: %v", filter.tags)
+	}
+	if filter.top != 5 {
+		t.Errorf("explicit -top overridden by the preset: %d", filter.top)
+	}
+	if !reflect.DeepEqual(filter.excludeOps, []string{"*Admin"}) {
+		t.Errorf("settings without an explicit flag not taken from the preset: %+v", filter)
+	}
+
+	filter = &operationFilter{}
+	p.apply(map[string]bool{"top": true}, &algorithm, filter)
+	if filter.top != 0 {
+		t.Errorf("explicit -top 0 overridden by the preset: %d", filter.top)
+	}
+
+	// An empty preset changes nothing
+	algorithm, filter = algoPath, &operationFilter{top: 3, readOnly: true}
+	(&preset{}).apply(map[string]bool{}, &algorithm, filter)
+	if algorithm != algoPath || !reflect.DeepEqual(filter, &operationFilter{top: 3, readOnly: true}) {
+		t.Errorf("empty preset changed the settings: %s, %+v", algorithm, filter)
+	}
+}
+
+func TestLoadPresets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, presetsFile)
+	err := os.WriteFile(path, []byte(`
+smoke:
+  algorithm: object
+  tags: [health]
+  top: 5
+nightly:
+  algorithm: all
+  excludeTags: [slow]
+  readOnly: true
+empty:
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	presets, err := loadPresets(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := presetNames(presets); got != "empty, full, nightly, regression, smoke" {
+		t.Errorf("presets %s", got)
+	}
+	if got, want := presets["smoke"], (&preset{Algorithm: algoObject, Tags: []string{"health"}, Top: 5}); !reflect.DeepEqual(got, want) {
+		t.Errorf("redefined smoke = %+v, want %+v", got, want)
+	}
+	if got, want := presets["nightly"], (&preset{Algorithm: algoAll, ExcludeTags: []string{"slow"}, ReadOnly: true}); !reflect.DeepEqual(got, want) {
+		t.Errorf("nightly = %+v, want %+v", got, want)
+	}
+	if got := presets["empty"]; !reflect.DeepEqual(got, &preset{}) {
+		t.Errorf("empty = %+v", got)
+	}
+	if builtinPresets["smoke"].Top != 20 || builtinPresets["smoke"].Algorithm != algoSimple {
+		t.Errorf("the file changed the built-in smoke preset: %+v", builtinPresets["smoke"])
+	}
+}
+
+func TestLoadPresetsMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), presetsFile)
+	presets, err := loadPresets(path, false)
+	if err != nil {
+		t.Fatalf("missing optional presets file: %v", err)
+	}
+	if !reflect.DeepEqual(presets, builtinPresets) {
+		t.Errorf("presets %v, want the built-in ones", presetNames(presets))
+	}
+	if _, err := loadPresets(path, true); err == nil {
+		t.Error("missing required presets file accepted")
+	}
+}
+
+func TestLoadPresetsInvalid(t *testing.T) {
+	for _, content := range []string{"smoke: [", "smoke:\n  top: many\n", "- smoke\n"} {
+		path := filepath.Join(t.TempDir(), presetsFile)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, required := range []bool{false, true} {
+			if _, err := loadPresets(path, required); err == nil {
+				t.Errorf("invalid presets file accepted: %q", content)
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gbatanov/meqa/mqplan"
+)
+
+// Passing this as the swagger file reads the spec from stdin, passing it as the output
+// directory writes the plans to stdout.
+const stdioPath = "-"
+
+// readSpecFromStdin copies the spec on stdin into a temporary file and returns its path. The
+// caller is responsible for removing the file. The file extension follows the content, since
+// the swagger loader decides between json and yaml by the extension.
+func readSpecFromStdin() (string, error) {
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	pattern := "meqa-swagger-*.yml"
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '{' {
+		pattern = "meqa-swagger-*.json"
+	}
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err = f.Write(content); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// dumpPlanToStdout writes the plan to stdout as a separate yaml document, so that several plans
+// can be written one after another. workPath is a scratch directory for the intermediate file.
+func dumpPlanToStdout(testPlan *mqplan.TestPlan, algo string, workPath string) error {
+	planFile := filepath.Join(workPath, algo+".yml")
+	err := testPlan.DumpToFile(planFile)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(planFile)
+	content, err := os.ReadFile(planFile)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(os.Stdout, "---\n# %s\n%s", algo+".yml", content)
+	return err
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gbatanov/meqa/mqswag"
+	"github.com/gbatanov/meqa/mqutil"
+	"github.com/go-openapi/spec"
+)
+
+// How often the watched files are polled for changes
+const watchInterval = time.Second
+
+// refFileRe matches $ref values that point into another file, in both json and yaml specs.
+var refFileRe = regexp.MustCompile(`\$ref["']?\s*:\s*["']?([^"'#\s]+)`)
+
+// watchAndRun generates the plans, then regenerates them every time the swagger file or one of the
+// files it refers to changes. It only returns if the swagger file can't be loaded at all.
+func watchAndRun(meqaPath *string, swaggerFile *string, algorithm *string, verbose *bool, whitelistFile *string, filter *operationFilter, config *manifestConfig) int {
+	code := run(meqaPath, swaggerFile, algorithm, verbose, whitelistFile, filter, config)
+	if code == exitUsage {
+		return code
+	}
+	ops := loadOperationSignatures(*swaggerFile, *meqaPath)
+	stamps := watchedFileStamps(*swaggerFile)
+	fmt.Printf("Watching %d file(s) for changes, press Ctrl-C to stop\n", len(stamps))
+
+	for {
+		time.Sleep(watchInterval)
+		newStamps := watchedFileStamps(*swaggerFile)
+		if stampsEqual(stamps, newStamps) {
+			continue
+		}
+		stamps = newStamps
+
+		newOps := loadOperationSignatures(*swaggerFile, *meqaPath)
+		if newOps == nil {
+			// Most likely the file is being saved, or is broken. Wait for the next change.
+			fmt.Println("Spec changed but can't be loaded, waiting for the next change")
+			continue
+		}
+		printAffectedOperations(ops, newOps)
+		ops = newOps
+		run(meqaPath, swaggerFile, algorithm, verbose, whitelistFile, filter, config)
+	}
+}
+
+// watchedFileStamps returns the modification time of the swagger file and every local file
+// it refers to through $ref, recursively.
+func watchedFileStamps(swaggerFile string) map[string]time.Time {
+	stamps := make(map[string]time.Time)
+	var visit func(path string)
+	visit = func(path string) {
+		if _, ok := stamps[path]; ok {
+			return
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			stamps[path] = time.Time{}
+			return
+		}
+		stamps[path] = fi.ModTime()
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		for _, match := range refFileRe.FindAllStringSubmatch(string(content), -1) {
+			ref := match[1]
+			if strings.Contains(ref, "://") {
+				continue
+			}
+			if !filepath.IsAbs(ref) {
+				ref = filepath.Join(filepath.Dir(path), ref)
+			}
+			visit(filepath.Clean(ref))
+		}
+	}
+	visit(filepath.Clean(swaggerFile))
+	return stamps
+}
+
+func stampsEqual(a map[string]time.Time, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, t := range a {
+		if u, ok := b[path]; !ok || !t.Equal(u) {
+			return false
+		}
+	}
+	return true
+}
+
+// loadOperationSignatures maps "METHOD path" to the json form of each operation in the spec, so that
+// two versions of the spec can be compared. It returns nil if the spec can't be loaded.
+func loadOperationSignatures(swaggerFile string, meqaPath string) map[string]string {
+	swagger, err := mqswag.CreateSwaggerFromURL(swaggerFile, meqaPath)
+	if err != nil {
+		mqutil.Logger.Printf("Error: %s", err.Error())
+		return nil
+	}
+	ops := make(map[string]string)
+	if swagger.Paths == nil {
+		return ops
+	}
+	for pathName, pathItem := range swagger.Paths.Paths {
+		for _, method := range mqswag.MethodAll {
+			opInterface, err := pathItem.JSONLookup(method)
+			if err != nil {
+				continue
+			}
+			op, _ := opInterface.(*spec.Operation)
+			if op == nil {
+				continue
+			}
+			opBytes, _ := json.Marshal(op)
+			// Path level parameters apply to every operation under the path.
+			paramBytes, _ := json.Marshal(pathItem.Parameters)
+			ops[strings.ToUpper(method)+" "+pathName] = string(opBytes) + string(paramBytes)
+		}
+	}
+	return ops
+}
+
+// printAffectedOperations prints the operations that were added (+), removed (-) or changed (~).
+func printAffectedOperations(oldOps map[string]string, newOps map[string]string) {
+	var lines []string
+	for name, sig := range newOps {
+		if oldSig, ok := oldOps[name]; !ok {
+			lines = append(lines, "  + "+name)
+		} else if oldSig != sig {
+			lines = append(lines, "  ~ "+name)
+		}
+	}
+	for name := range oldOps {
+		if _, ok := newOps[name]; !ok {
+			lines = append(lines, "  - "+name)
+		}
+	}
+	if len(lines) == 0 {
+		fmt.Println("Spec changed, no operations affected. Regenerating test plans.")
+		return
+	}
+	// sort by operation name, not by the change marker
+	sort.Slice(lines, func(i, j int) bool { return lines[i][4:] < lines[j][4:] })
+	fmt.Println("Spec changed, affected operations:")
+	fmt.Println(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,142 @@
+package api_util
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// ArrayCompareMode selects how InterfaceEquals compares two arrays.
+type ArrayCompareMode int
+
+const (
+	ArrayIgnore      ArrayCompareMode = iota // arrays are always considered equal
+	ArrayStrict                              // same length, and equal entries in the same order
+	ArrayAsSet                               // same length, and every entry has an equal entry in any order
+	ArraySubset                              // every entry of criteria has its own equal entry in existing
+	ArrayContainsOne                         // at least one entry of criteria equals an entry of existing
+)
+
+// DefaultArrayCompareMode is used by InterfaceEquals. Subset follows the rest of InterfaceEquals,
+// where everything in the criteria must be found in the existing value.
+var DefaultArrayCompareMode = ArraySubset
+
+// ArrayCompareModeFromString converts the mode names used in plans and config ("strict", "set",
+// "subset", "containsOne", "ignore") to the mode. ok is false for an unknown name.
+func ArrayCompareModeFromString(name string) (mode ArrayCompareMode, ok bool) {
+	switch name {
+	case "ignore":
+		return ArrayIgnore, true
+	case "strict":
+		return ArrayStrict, true
+	case "set":
+		return ArrayAsSet, true
+	case "subset":
+		return ArraySubset, true
+	case "containsOne":
+		return ArrayContainsOne, true
+	}
+	return DefaultArrayCompareMode, false
+}
+
+// toInterfaceArray converts any array or slice into []interface{}.
+func toInterfaceArray(a interface{}) []interface{} {
+	if ar, ok := a.([]interface{}); ok {
+		return ar
+	}
+	v := reflect.ValueOf(a)
+	ar := make([]interface{}, v.Len())
+	for i := range ar {
+		ar[i] = v.Index(i).Interface()
+	}
+	return ar
+}
+
+// arrayEquals compares two arrays found at fieldPath. Entries are compared with InterfaceEquals,
+// so entries that are maps only need to match the fields present in the criteria entry.
+func arrayEquals(criteria interface{}, existing interface{}, fieldPath []string, mode ArrayCompareMode) bool {
+	if mode == ArrayIgnore {
+		return true
+	}
+	cArray := toInterfaceArray(criteria)
+	eArray := toInterfaceArray(existing)
+
+	switch mode {
+	case ArrayStrict:
+		if len(cArray) != len(eArray) {
+			return false
+		}
+		for i := range cArray {
+			if !interfaceEqualsAt(cArray[i], eArray[i], appendPath(fieldPath, strconv.Itoa(i)), mode) {
+				return false
+			}
+		}
+		return true
+	case ArrayContainsOne:
+		if len(cArray) == 0 {
+			return true
+		}
+		for i, c := range cArray {
+			for _, e := range eArray {
+				if interfaceEqualsAt(c, e, appendPath(fieldPath, strconv.Itoa(i)), mode) {
+					return true
+				}
+			}
+		}
+		return false
+	case ArrayAsSet:
+		if len(cArray) != len(eArray) {
+			return false
+		}
+	}
+
+	// Set and subset: every criteria entry takes a distinct existing entry that equals it.
+	_, matched := matchArrays(cArray, eArray, fieldPath, mode)
+	return matched == len(cArray)
+}
+
+// matchArrays pairs criteria entries with distinct existing entries that equal them, pairing as many
+// as possible. Map entries only need to match the fields in the criteria entry, so an existing entry
+// can equal several criteria entries; a first fit search could use up the entry another criteria
+// entry needed, hence the augmenting path search (Kuhn's algorithm). matchOf[i] is the index of the
+// existing entry paired with criteria entry i, -1 if none.
+func matchArrays(cArray []interface{}, eArray []interface{}, fieldPath []string, mode ArrayCompareMode) (matchOf []int, matched int) {
+	candidates := make([][]int, len(cArray))
+	for i, c := range cArray {
+		p := appendPath(fieldPath, strconv.Itoa(i))
+		for j, e := range eArray {
+			if interfaceEqualsAt(c, e, p, mode) {
+				candidates[i] = append(candidates[i], j)
+			}
+		}
+	}
+
+	matchOf = make([]int, len(cArray))
+	owner := make([]int, len(eArray)) // the criteria entry paired with each existing entry
+	for i := range matchOf {
+		matchOf[i] = -1
+	}
+	for j := range owner {
+		owner[j] = -1
+	}
+	var augment func(i int, visited []bool) bool
+	augment = func(i int, visited []bool) bool {
+		for _, j := range candidates[i] {
+			if visited[j] {
+				continue
+			}
+			visited[j] = true
+			if owner[j] < 0 || augment(owner[j], visited) {
+				owner[j] = i
+				matchOf[i] = j
+				return true
+			}
+		}
+		return false
+	}
+	for i := range cArray {
+		if augment(i, make([]bool, len(eArray))) {
+			matched++
+		}
+	}
+	return matchOf, matched
+}
@@ -0,0 +1,83 @@
+package api_util
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// decodeJson decodes a json literal used in the tests.
+func decodeJson(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("bad json %s: %v", s, err)
+	}
+	return v
+}
+
+func TestArrayCompareModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     ArrayCompareMode
+		criteria string
+		existing string
+		want     bool
+	}{
+		{"ignore different", ArrayIgnore, `[1,2]`, `[3]`, true},
+
+		{"strict equal", ArrayStrict, `[1,2,3]`, `[1,2,3]`, true},
+		{"strict order", ArrayStrict, `[1,2,3]`, `[3,2,1]`, false},
+		{"strict length", ArrayStrict, `[1,2]`, `[1,2,3]`, false},
+		{"strict partial maps", ArrayStrict, `[{"a":1}]`, `[{"a":1,"b":2}]`, true},
+
+		{"set any order", ArrayAsSet, `[1,2,3]`, `[3,1,2]`, true},
+		{"set length", ArrayAsSet, `[1,2]`, `[2,1,3]`, false},
+		{"set duplicates", ArrayAsSet, `[1,1,2]`, `[1,2,2]`, false},
+		{"set partial maps", ArrayAsSet, `[{"a":1},{"a":1,"b":2}]`, `[{"a":1,"b":2},{"a":1,"c":3}]`, true},
+
+		{"subset", ArraySubset, `[2,3]`, `[1,2,3]`, true},
+		{"subset missing", ArraySubset, `[2,4]`, `[1,2,3]`, false},
+		{"subset duplicates need distinct entries", ArraySubset, `[2,2]`, `[1,2,3]`, false},
+		{"subset empty", ArraySubset, `[]`, `[1]`, true},
+		{"subset partial maps", ArraySubset, `[{"a":1},{"a":1,"b":2}]`, `[{"a":1,"b":2},{"a":1,"c":3}]`, true},
+		{"subset partial maps no room", ArraySubset, `[{"a":1},{"a":1,"b":2}]`, `[{"a":1,"b":2}]`, false},
+		{"subset nested", ArraySubset, `{"x":[{"id":2}]}`, `{"x":[{"id":1},{"id":2,"n":"b"}]}`, true},
+
+		{"contains one", ArrayContainsOne, `[4,2]`, `[1,2,3]`, true},
+		{"contains none", ArrayContainsOne, `[4,5]`, `[1,2,3]`, false},
+		{"contains one empty", ArrayContainsOne, `[]`, `[1]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := InterfaceEqualsMode(decodeJson(t, tt.criteria), decodeJson(t, tt.existing), tt.mode)
+			if got != tt.want {
+				t.Errorf("InterfaceEqualsMode(%s, %s) = %v, want %v", tt.criteria, tt.existing, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterfaceEqualsDefaultArrayMode(t *testing.T) {
+	if DefaultArrayCompareMode != ArraySubset {
+		t.Fatalf("DefaultArrayCompareMode = %v, want ArraySubset", DefaultArrayCompareMode)
+	}
+	if !InterfaceEquals(decodeJson(t, `[3,1]`), decodeJson(t, `[1,2,3]`)) {
+		t.Error("InterfaceEquals should accept criteria entries found in any order")
+	}
+	if InterfaceEquals(decodeJson(t, `[1,4]`), decodeJson(t, `[1,2,3]`)) {
+		t.Error("InterfaceEquals should reject a criteria entry that isn't found")
+	}
+}
+
+func TestArrayCompareModeFromString(t *testing.T) {
+	for name, want := range map[string]ArrayCompareMode{
+		"ignore": ArrayIgnore, "strict": ArrayStrict, "set": ArrayAsSet, "subset": ArraySubset, "containsOne": ArrayContainsOne,
+	} {
+		if got, ok := ArrayCompareModeFromString(name); !ok || got != want {
+			t.Errorf("ArrayCompareModeFromString(%q) = %v, %v", name, got, ok)
+		}
+	}
+	if _, ok := ArrayCompareModeFromString("bogus"); ok {
+		t.Error("ArrayCompareModeFromString accepted an unknown name")
+	}
+}
@@ -0,0 +1,202 @@
+package api_util
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Kinds of differences reported by Diff
+const (
+	DiffChanged    = "changed"    // both sides have the field, with different values
+	DiffMissing    = "missing"    // the expected field is not in the actual value
+	DiffUnexpected = "unexpected" // the actual value has a field that is not expected
+)
+
+// Difference is a single difference between an expected and an actual value.
+type Difference struct {
+	Path     string      `json:"path"` // json pointer to the field, "" for the whole value
+	Kind     string      `json:"kind"`
+	Expected interface{} `json:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty"`
+}
+
+// Diff returns every difference between the expected and actual values, in a stable order. Maps are
+// compared field by field and arrays entry by entry, so unlike InterfaceEquals, extra fields and
+// reordered entries are differences; see DiffCriteria for InterfaceEquals semantics. Numbers are
+// compared by value, and fields covered by the ignore rules (see SetIgnoreRules) are skipped.
+func Diff(expected interface{}, actual interface{}) []Difference {
+	return diffValues(nil, nil, expected, actual)
+}
+
+func diffValues(diffs []Difference, fieldPath []string, expected interface{}, actual interface{}) []Difference {
+	if len(fieldPath) > 0 && IsIgnoredPath(fieldPath) {
+		return diffs
+	}
+	em, eIsMap := expected.(map[string]interface{})
+	am, aIsMap := actual.(map[string]interface{})
+	if eIsMap && aIsMap {
+		for _, k := range sortedKeys(em) {
+			p := appendPath(fieldPath, k)
+			if av, ok := am[k]; ok {
+				diffs = diffValues(diffs, p, em[k], av)
+			} else if !IsIgnoredPath(p) {
+				diffs = append(diffs, Difference{MakePointer(p), DiffMissing, em[k], nil})
+			}
+		}
+		for _, k := range sortedKeys(am) {
+			p := appendPath(fieldPath, k)
+			if _, ok := em[k]; !ok && !IsIgnoredPath(p) {
+				diffs = append(diffs, Difference{MakePointer(p), DiffUnexpected, nil, am[k]})
+			}
+		}
+		return diffs
+	}
+
+	ea, eIsArray := expected.([]interface{})
+	aa, aIsArray := actual.([]interface{})
+	if eIsArray && aIsArray {
+		for i := 0; i < len(ea) || i < len(aa); i++ {
+			p := appendPath(fieldPath, strconv.Itoa(i))
+			switch {
+			case IsIgnoredPath(p):
+			case i >= len(aa):
+				diffs = append(diffs, Difference{MakePointer(p), DiffMissing, ea[i], nil})
+			case i >= len(ea):
+				diffs = append(diffs, Difference{MakePointer(p), DiffUnexpected, nil, aa[i]})
+			default:
+				diffs = diffValues(diffs, p, ea[i], aa[i])
+			}
+		}
+		return diffs
+	}
+
+	// Same number rules as InterfaceEquals.
+	if e, ok, eIsString := numberValue(expected); ok {
+		if a, ok, aIsString := numberValue(actual); ok && !(eIsString && aIsString) && e.equals(a) {
+			return diffs
+		}
+	}
+	if !jsonEqual(expected, actual) {
+		diffs = append(diffs, Difference{MakePointer(fieldPath), DiffChanged, expected, actual})
+	}
+	return diffs
+}
+
+// DiffCriteria returns the differences that make InterfaceEquals(criteria, existing) fail, and none
+// when it succeeds. It follows the same rules: fields of existing that aren't in criteria are not
+// differences, and arrays are compared according to DefaultArrayCompareMode.
+func DiffCriteria(criteria interface{}, existing interface{}) []Difference {
+	return DiffCriteriaMode(criteria, existing, DefaultArrayCompareMode)
+}
+
+// DiffCriteriaMode is DiffCriteria with arrays, at any depth, compared according to mode, like
+// InterfaceEqualsMode. When entries are matched in any order (ArrayAsSet, ArraySubset), a criteria
+// entry without a match is reported missing at its index in criteria, and in ArrayAsSet an existing
+// entry without a match is reported unexpected at its index in existing. ArrayContainsOne reports a
+// single change of the whole array.
+func DiffCriteriaMode(criteria interface{}, existing interface{}, mode ArrayCompareMode) []Difference {
+	return diffCriteria(nil, nil, criteria, existing, mode)
+}
+
+func diffCriteria(diffs []Difference, fieldPath []string, criteria interface{}, existing interface{}, mode ArrayCompareMode) []Difference {
+	if interfaceEqualsAt(criteria, existing, fieldPath, mode) {
+		return diffs
+	}
+	cm, cIsMap := criteria.(map[string]interface{})
+	em, eIsMap := existing.(map[string]interface{})
+	if cIsMap && eIsMap {
+		for _, k := range sortedKeys(cm) {
+			p := appendPath(fieldPath, k)
+			if ev, ok := em[k]; ok {
+				diffs = diffCriteria(diffs, p, cm[k], ev, mode)
+			} else if !interfaceEqualsAt(cm[k], nil, p, mode) {
+				diffs = append(diffs, Difference{MakePointer(p), DiffMissing, cm[k], nil})
+			}
+		}
+		return diffs
+	}
+
+	if criteria == nil || existing == nil {
+		return append(diffs, Difference{MakePointer(fieldPath), DiffChanged, criteria, existing})
+	}
+	cKind, eKind := reflect.TypeOf(criteria).Kind(), reflect.TypeOf(existing).Kind()
+	if (cKind == reflect.Array || cKind == reflect.Slice) && (eKind == reflect.Array || eKind == reflect.Slice) {
+		cArray, eArray := toInterfaceArray(criteria), toInterfaceArray(existing)
+		switch mode {
+		case ArrayStrict:
+			for i := 0; i < len(cArray) || i < len(eArray); i++ {
+				p := appendPath(fieldPath, strconv.Itoa(i))
+				switch {
+				case i >= len(eArray):
+					diffs = append(diffs, Difference{MakePointer(p), DiffMissing, cArray[i], nil})
+				case i >= len(cArray):
+					diffs = append(diffs, Difference{MakePointer(p), DiffUnexpected, nil, eArray[i]})
+				default:
+					diffs = diffCriteria(diffs, p, cArray[i], eArray[i], mode)
+				}
+			}
+			return diffs
+		case ArrayAsSet, ArraySubset:
+			matchOf, _ := matchArrays(cArray, eArray, fieldPath, mode)
+			used := make([]bool, len(eArray))
+			for i, j := range matchOf {
+				if j < 0 {
+					p := appendPath(fieldPath, strconv.Itoa(i))
+					diffs = append(diffs, Difference{MakePointer(p), DiffMissing, cArray[i], nil})
+				} else {
+					used[j] = true
+				}
+			}
+			if mode == ArrayAsSet {
+				for j, e := range eArray {
+					if !used[j] {
+						p := appendPath(fieldPath, strconv.Itoa(j))
+						diffs = append(diffs, Difference{MakePointer(p), DiffUnexpected, nil, e})
+					}
+				}
+			}
+			return diffs
+		}
+	}
+	return append(diffs, Difference{MakePointer(fieldPath), DiffChanged, criteria, existing})
+}
+
+// compactJson is used to print values in diffs.
+func compactJson(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+// FormatDiff renders the differences for humans, one per line: "~" for changed values, "-" for
+// missing ones and "+" for unexpected ones. With color, expected values are green and actual values
+// are red.
+func FormatDiff(diffs []Difference, color bool) string {
+	paint := func(c string, s string) string {
+		if !color {
+			return s
+		}
+		return c + s + END
+	}
+	var b strings.Builder
+	for _, d := range diffs {
+		path := d.Path
+		if len(path) == 0 {
+			path = "(root)"
+		}
+		switch d.Kind {
+		case DiffMissing:
+			fmt.Fprintf(&b, "- %s: expected %s, missing\n", path, paint(GREEN, compactJson(d.Expected)))
+		case DiffUnexpected:
+			fmt.Fprintf(&b, "+ %s: unexpected %s\n", path, paint(RED, compactJson(d.Actual)))
+		default:
+			fmt.Fprintf(&b, "~ %s: expected %s, got %s\n", path, paint(GREEN, compactJson(d.Expected)), paint(RED, compactJson(d.Actual)))
+		}
+	}
+	return b.String()
+}
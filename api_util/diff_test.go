@@ -0,0 +1,97 @@
+package api_util
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	expected := decodeJson(t, `{"a":1,"b":[1,2,3],"c":{"x":"y"},"d/e":1}`)
+	actual := decodeJson(t, `{"a":1.0,"b":[1,5],"c":{"x":"q","n":true}}`)
+	want := []Difference{
+		{"/b/1", DiffChanged, 2.0, 5.0},
+		{"/b/2", DiffMissing, 3.0, nil},
+		{"/c/x", DiffChanged, "y", "q"},
+		{"/c/n", DiffUnexpected, nil, true},
+		{"/d~1e", DiffMissing, 1.0, nil},
+	}
+	if got := Diff(expected, actual); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %v\nwant %v", got, want)
+	}
+	if got := Diff(expected, expected); len(got) != 0 {
+		t.Errorf("Diff of equal values = %v", got)
+	}
+}
+
+func TestDiffIgnoreRules(t *testing.T) {
+	defer SetIgnoreRules(nil)
+	if err := SetIgnoreRules([]string{"updatedAt", "$.items[*].id"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := decodeJson(t, `{"updatedAt":"x","items":[{"id":1,"n":"a"}]}`)
+	actual := decodeJson(t, `{"updatedAt":"y","items":[{"id":2,"n":"a"}],"extra":{"updatedAt":1}}`)
+	want := []Difference{{"/extra", DiffUnexpected, nil, map[string]interface{}{"updatedAt": 1.0}}}
+	if got := Diff(expected, actual); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %v\nwant %v", got, want)
+	}
+}
+
+func TestDiffCriteriaMatchesInterfaceEquals(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     ArrayCompareMode
+		criteria string
+		existing string
+		want     []Difference
+	}{
+		{"extra fields", ArraySubset, `{"a":1}`, `{"a":1,"b":2}`, nil},
+		{"reordered subset", ArraySubset, `[{"id":2},{"id":1}]`, `[{"id":1,"n":"a"},{"id":2},{"id":3}]`, nil},
+		{"partial maps", ArraySubset, `[{"a":1},{"a":1,"b":2}]`, `[{"a":1,"b":2},{"a":1,"c":3}]`, nil},
+		{"nil criteria field", ArraySubset, `{"a":null}`, `{}`, nil},
+		{"changed field", ArraySubset, `{"a":1,"b":{"c":"x"}}`, `{"a":1,"b":{"c":"y","d":1}}`,
+			[]Difference{{"/b/c", DiffChanged, "x", "y"}}},
+		{"missing field", ArraySubset, `{"a":1,"b":2}`, `{"a":1}`,
+			[]Difference{{"/b", DiffMissing, 2.0, nil}}},
+		{"subset missing entry", ArraySubset, `{"l":[1,4]}`, `{"l":[3,2,1]}`,
+			[]Difference{{"/l/1", DiffMissing, 4.0, nil}}},
+		{"set unexpected entry", ArrayAsSet, `[1,2]`, `[2,3,1]`,
+			[]Difference{{"/1", DiffUnexpected, nil, 3.0}}},
+		{"strict order", ArrayStrict, `[1,2]`, `[2,1]`,
+			[]Difference{{"/0", DiffChanged, 1.0, 2.0}, {"/1", DiffChanged, 2.0, 1.0}}},
+		{"strict length", ArrayStrict, `[1]`, `[1,2]`,
+			[]Difference{{"/1", DiffUnexpected, nil, 2.0}}},
+		{"contains one", ArrayContainsOne, `[4,5]`, `[1,2]`,
+			[]Difference{{"", DiffChanged, []interface{}{4.0, 5.0}, []interface{}{1.0, 2.0}}}},
+		{"ignore arrays", ArrayIgnore, `{"l":[4]}`, `{"l":[1]}`, nil},
+		{"type change", ArraySubset, `{"a":[1]}`, `{"a":"x"}`,
+			[]Difference{{"/a", DiffChanged, []interface{}{1.0}, "x"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			criteria, existing := decodeJson(t, tt.criteria), decodeJson(t, tt.existing)
+			got := DiffCriteriaMode(criteria, existing, tt.mode)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffCriteriaMode = %v\nwant %v", got, tt.want)
+			}
+			if equal := InterfaceEqualsMode(criteria, existing, tt.mode); equal != (len(got) == 0) {
+				t.Errorf("InterfaceEqualsMode = %v but %d differences", equal, len(got))
+			}
+		})
+	}
+}
+
+func TestFormatDiff(t *testing.T) {
+	diffs := []Difference{
+		{"/a", DiffChanged, 1, 2},
+		{"/b", DiffMissing, "x", nil},
+		{"", DiffUnexpected, nil, true},
+	}
+	want := "~ /a: expected 1, got 2\n- /b: expected \"x\", missing\n+ (root): unexpected true\n"
+	if got := FormatDiff(diffs, false); got != want {
+		t.Errorf("FormatDiff = %q, want %q", got, want)
+	}
+	if colored := FormatDiff(diffs, true); !strings.Contains(colored, GREEN+"1"+END) || !strings.Contains(colored, RED+"2"+END) {
+		t.Errorf("FormatDiff with color = %q", colored)
+	}
+}
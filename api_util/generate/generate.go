@@ -0,0 +1,394 @@
+// Package generate creates random values that conform to a swagger schema. It is meant to be shared
+// by the tools that need fake data: plan generation, mock servers, fuzzers and data seeding.
+package generate
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/spec"
+
+	mqutil "github.com/mmanjoura/vmie-api-qa/api_util"
+)
+
+// Options controls the generation. The zero value is usable.
+type Options struct {
+	Definitions  spec.Definitions // used to resolve "#/definitions/..." references
+	MaxDepth     int              // nesting limit, protects against recursive schemas. Default 5.
+	MaxItems     int              // upper bound on the length of generated arrays. Default 3.
+	RequiredOnly bool             // only generate the required properties of objects
+	UseExamples  bool             // return the schema's example when there is one
+	Rand         *rand.Rand       // source of randomness, seeded with the current time if nil
+}
+
+const (
+	defaultMaxDepth  = 5
+	defaultMaxItems  = 3
+	defaultMaxLength = 10
+	letters          = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+type generator struct {
+	opts Options
+	rand *rand.Rand
+}
+
+func newGenerator(opts *Options) *generator {
+	g := &generator{}
+	if opts != nil {
+		g.opts = *opts
+	}
+	if g.opts.MaxDepth <= 0 {
+		g.opts.MaxDepth = defaultMaxDepth
+	}
+	if g.opts.MaxItems <= 0 {
+		g.opts.MaxItems = defaultMaxItems
+	}
+	g.rand = g.opts.Rand
+	if g.rand == nil {
+		g.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return g
+}
+
+// GenerateObject generates an object for the schema, which must describe an object.
+func GenerateObject(schema *spec.Schema, opts *Options) (map[string]interface{}, error) {
+	value, err := Generate(schema, opts)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, mqutil.NewError(mqutil.ErrInvalid, fmt.Sprintf("schema doesn't describe an object: %v", value))
+	}
+	return obj, nil
+}
+
+// Generate generates a value of any type for the schema.
+func Generate(schema *spec.Schema, opts *Options) (interface{}, error) {
+	return newGenerator(opts).generate(schema, 0)
+}
+
+// resolve follows the schema's reference, if any.
+func (g *generator) resolve(schema *spec.Schema) (*spec.Schema, error) {
+	for schema.Ref.GetURL() != nil {
+		tokens := schema.Ref.GetPointer().DecodedTokens()
+		if len(tokens) != 2 || tokens[0] != "definitions" {
+			return nil, mqutil.NewError(mqutil.ErrInvalid, fmt.Sprintf("Invalid reference: %s", schema.Ref.GetURL()))
+		}
+		referred, ok := g.opts.Definitions[tokens[1]]
+		if !ok {
+			return nil, mqutil.NewError(mqutil.ErrNotFound, fmt.Sprintf("Reference object not found: %s", schema.Ref.GetURL()))
+		}
+		schema = &referred
+	}
+	return schema, nil
+}
+
+func (g *generator) generate(schema *spec.Schema, depth int) (interface{}, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	schema, err := g.resolve(schema)
+	if err != nil {
+		return nil, err
+	}
+	if g.opts.UseExamples && schema.Example != nil {
+		return schema.Example, nil
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[g.rand.Intn(len(schema.Enum))], nil
+	}
+	if len(schema.AllOf) > 0 || schema.Type.Contains("object") || (len(schema.Type) == 0 && len(schema.Properties) > 0) {
+		return g.generateObject(schema, depth)
+	}
+	switch {
+	case schema.Type.Contains("array"):
+		return g.generateArray(schema, depth)
+	case schema.Type.Contains("string"):
+		return g.generateString(schema)
+	case schema.Type.Contains("integer"):
+		return g.generateInteger(schema)
+	case schema.Type.Contains("number"):
+		return g.generateNumber(schema)
+	case schema.Type.Contains("boolean"):
+		return g.rand.Intn(2) == 0, nil
+	}
+	// No type, anything goes.
+	return g.randomString(1, defaultMaxLength), nil
+}
+
+func (g *generator) generateObject(schema *spec.Schema, depth int) (interface{}, error) {
+	obj := make(map[string]interface{})
+	if depth >= g.opts.MaxDepth {
+		// Recursive or very deep schema, stop here with an empty object.
+		return obj, nil
+	}
+	for i := range schema.AllOf {
+		part, err := g.generate(&schema.AllOf[i], depth)
+		if err != nil {
+			return nil, err
+		}
+		if partObj, ok := part.(map[string]interface{}); ok {
+			for k, v := range partObj {
+				obj[k] = v
+			}
+		}
+	}
+	required := make(map[string]bool)
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	// Go through the properties in order, so that a seeded Rand always gives the same object.
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop := schema.Properties[name]
+		if g.opts.RequiredOnly && !required[name] {
+			continue
+		}
+		if prop.ReadOnly {
+			continue
+		}
+		value, err := g.generate(&prop, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		obj[name] = value
+	}
+	return obj, nil
+}
+
+func (g *generator) generateArray(schema *spec.Schema, depth int) (interface{}, error) {
+	arr := []interface{}{}
+	if schema.Items == nil || depth >= g.opts.MaxDepth {
+		return arr, nil
+	}
+	itemSchema := schema.Items.Schema
+	if itemSchema == nil && len(schema.Items.Schemas) > 0 {
+		itemSchema = &schema.Items.Schemas[0]
+	}
+	min, max := 1, g.opts.MaxItems
+	if schema.MinItems != nil {
+		min = int(*schema.MinItems)
+	}
+	if schema.MaxItems != nil && int(*schema.MaxItems) < max {
+		max = int(*schema.MaxItems)
+	}
+	if max < min {
+		max = min
+	}
+	n := min + g.rand.Intn(max-min+1)
+	for i := 0; i < n; i++ {
+		value, err := g.generate(itemSchema, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, value)
+	}
+	return arr, nil
+}
+
+func (g *generator) randomString(min int, max int) string {
+	if max < min {
+		max = min
+	}
+	n := min + g.rand.Intn(max-min+1)
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteByte(letters[g.rand.Intn(len(letters))])
+	}
+	return b.String()
+}
+
+func (g *generator) generateString(schema *spec.Schema) (string, error) {
+	if len(schema.Pattern) > 0 {
+		// The pattern decides the content, so formats don't apply. The lengths are only bounded when
+		// the schema bounds them.
+		min, max := 0, math.MaxInt
+		if schema.MinLength != nil {
+			min = int(*schema.MinLength)
+		}
+		if schema.MaxLength != nil {
+			max = int(*schema.MaxLength)
+		}
+		return g.generatePattern(schema.Pattern, min, max)
+	}
+	switch schema.Format {
+	case "date-time":
+		return g.randomTime().Format(time.RFC3339), nil
+	case "date":
+		return g.randomTime().Format("2006-01-02"), nil
+	case "uuid":
+		b := make([]byte, 16)
+		g.rand.Read(b)
+		b[6] = (b[6] & 0x0f) | 0x40
+		b[8] = (b[8] & 0x3f) | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+	case "email":
+		return strings.ToLower(g.randomString(5, 10)) + "@example.com", nil
+	case "uri", "url":
+		return "https://example.com/" + strings.ToLower(g.randomString(5, 10)), nil
+	case "hostname":
+		return strings.ToLower(g.randomString(5, 10)) + ".example.com", nil
+	case "ipv4":
+		return fmt.Sprintf("10.%d.%d.%d", g.rand.Intn(256), g.rand.Intn(256), 1+g.rand.Intn(254)), nil
+	case "byte":
+		return base64.StdEncoding.EncodeToString([]byte(g.randomString(5, 10))), nil
+	}
+	min, max := 1, defaultMaxLength
+	if schema.MinLength != nil {
+		min = int(*schema.MinLength)
+	}
+	if schema.MaxLength != nil {
+		max = int(*schema.MaxLength)
+		if schema.MinLength == nil && max < min {
+			// maxLength 0 only allows the empty string
+			min = max
+		}
+	} else if max < min {
+		max = min + defaultMaxLength
+	}
+	return g.randomString(min, max), nil
+}
+
+// randomTime returns a time within a year from now, in whole seconds.
+func (g *generator) randomTime() time.Time {
+	offset := time.Duration(g.rand.Int63n(int64(365 * 24 * time.Hour)))
+	return time.Now().UTC().Add(-offset).Truncate(time.Second)
+}
+
+// numberRange returns the bounds of a numeric schema, defaulting to [0, 1000].
+func numberRange(schema *spec.Schema) (float64, float64) {
+	min, max := 0.0, 1000.0
+	if schema.Minimum != nil {
+		min = *schema.Minimum
+		if schema.Maximum == nil {
+			max = min + 1000
+		}
+	}
+	if schema.Maximum != nil {
+		max = *schema.Maximum
+		if schema.Minimum == nil && max < min {
+			min = max - 1000
+		}
+	}
+	return min, max
+}
+
+// toInt64 converts a bound to int64, saturating instead of overflowing.
+func toInt64(f float64) int64 {
+	switch {
+	case f >= math.MaxInt64:
+		return math.MaxInt64
+	case f <= math.MinInt64:
+		return math.MinInt64
+	}
+	return int64(f)
+}
+
+// randomInt64 returns a random integer in [min, max], for any range of int64.
+func (g *generator) randomInt64(min int64, max int64) int64 {
+	span := uint64(max - min) // the wrapped difference is the right unsigned span
+	if span == math.MaxUint64 {
+		return int64(g.rand.Uint64())
+	}
+	return min + int64(g.rand.Uint64()%(span+1))
+}
+
+// unsatisfiable returns the error for a schema that no value can satisfy.
+func unsatisfiable(reason string) error {
+	return mqutil.NewError(mqutil.ErrInvalid, "schema can't be satisfied: "+reason)
+}
+
+// How far integerStep looks for an integer multiple of a fractional step
+const maxStepMultiplier = 1e6
+
+// integerStep returns the smallest positive integer that is a multiple of step. It returns false if
+// there is none that fits in an int64, or none close enough to be found.
+func integerStep(step float64) (int64, bool) {
+	for m := 1.0; m <= maxStepMultiplier; m++ {
+		v := m * step
+		if v >= math.MaxInt64 {
+			return 0, false
+		}
+		if r := math.Round(v); r > 0 && math.Abs(v-r) <= 1e-9*r {
+			return int64(r), true
+		}
+	}
+	return 0, false
+}
+
+func (g *generator) generateInteger(schema *spec.Schema) (int64, error) {
+	minF, maxF := numberRange(schema)
+	min, max := toInt64(math.Ceil(minF)), toInt64(math.Floor(maxF))
+	if schema.ExclusiveMinimum && float64(min) == minF && min < math.MaxInt64 {
+		min++
+	}
+	if schema.ExclusiveMaximum && float64(max) == maxF && max > math.MinInt64 {
+		max--
+	}
+	if max < min {
+		return 0, unsatisfiable("no integer within the bounds")
+	}
+	if schema.MultipleOf != nil && *schema.MultipleOf > 0 {
+		// The integers that are multiples of a fractional step, 2.5 say, are the multiples of a
+		// bigger integer step, 5.
+		step, ok := integerStep(*schema.MultipleOf)
+		if !ok {
+			return 0, unsatisfiable("no integer is a multiple of multipleOf")
+		}
+		first := min / step * step
+		if first < min {
+			if first > math.MaxInt64-step {
+				return 0, unsatisfiable("no multiple within the bounds")
+			}
+			first += step
+		}
+		if first > max {
+			return 0, unsatisfiable("no multiple within the bounds")
+		}
+		return first + step*g.randomInt64(0, int64(uint64(max-first)/uint64(step))), nil
+	}
+	return g.randomInt64(min, max), nil
+}
+
+func (g *generator) generateNumber(schema *spec.Schema) (float64, error) {
+	min, max := numberRange(schema)
+	if max < min || (max == min && (schema.ExclusiveMinimum || schema.ExclusiveMaximum)) {
+		return 0, unsatisfiable("no number within the bounds")
+	}
+	if max == min {
+		return min, nil
+	}
+	if schema.MultipleOf != nil && *schema.MultipleOf > 0 {
+		// Pick a multiple within the bounds rather than rounding a value, which can leave them
+		step := *schema.MultipleOf
+		lo, hi := math.Ceil(min/step), math.Floor(max/step)
+		if schema.ExclusiveMinimum && lo*step == min {
+			lo++
+		}
+		if schema.ExclusiveMaximum && hi*step == max {
+			hi--
+		}
+		if hi < lo {
+			return 0, unsatisfiable("no multiple within the bounds")
+		}
+		return math.Min(lo+math.Floor(g.rand.Float64()*(hi-lo+1)), hi) * step, nil
+	}
+	for {
+		v := min + g.rand.Float64()*(max-min)
+		if (schema.ExclusiveMinimum && v == min) || (schema.ExclusiveMaximum && v == max) {
+			continue
+		}
+		return v, nil
+	}
+}
@@ -0,0 +1,285 @@
+package generate
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+func schemaFromJson(t *testing.T, s string) *spec.Schema {
+	t.Helper()
+	schema := &spec.Schema{}
+	if err := json.Unmarshal([]byte(s), schema); err != nil {
+		t.Fatalf("bad schema %s: %v", s, err)
+	}
+	return schema
+}
+
+func TestGenerateStringLength(t *testing.T) {
+	opts := &Options{Rand: rand.New(rand.NewSource(1))}
+	for _, tt := range []struct {
+		schema   string
+		min, max int
+	}{
+		{`{"type":"string","maxLength":0}`, 0, 0},
+		{`{"type":"string","maxLength":1}`, 1, 1},
+		{`{"type":"string","minLength":20}`, 20, 30},
+		{`{"type":"string","minLength":2,"maxLength":4}`, 2, 4},
+	} {
+		schema := schemaFromJson(t, tt.schema)
+		for i := 0; i < 50; i++ {
+			v, err := Generate(schema, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := len(v.(string)); n < tt.min || n > tt.max {
+				t.Fatalf("%s generated %q", tt.schema, v)
+			}
+		}
+	}
+}
+
+func TestGenerateNumberBounds(t *testing.T) {
+	opts := &Options{Rand: rand.New(rand.NewSource(1))}
+	for _, tt := range []struct {
+		schema   string
+		min, max float64
+		multiple float64
+	}{
+		{`{"type":"number","maximum":5,"multipleOf":3}`, -995, 5, 3},
+		{`{"type":"number","minimum":1,"maximum":7,"multipleOf":3}`, 1, 7, 3},
+		{`{"type":"number","minimum":0,"maximum":1,"multipleOf":0.25,"exclusiveMaximum":true}`, 0, 0.75, 0.25},
+		{`{"type":"integer","maximum":5,"multipleOf":3}`, -995, 5, 3},
+		{`{"type":"integer","minimum":-7,"maximum":7,"multipleOf":3}`, -7, 7, 3},
+		{`{"type":"integer","minimum":1,"maximum":3,"exclusiveMinimum":true,"exclusiveMaximum":true}`, 2, 2, 1},
+	} {
+		schema := schemaFromJson(t, tt.schema)
+		for i := 0; i < 200; i++ {
+			v, err := Generate(schema, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var f float64
+			switch n := v.(type) {
+			case int64:
+				f = float64(n)
+			case float64:
+				f = n
+			}
+			if f < tt.min || f > tt.max || math.Abs(math.Remainder(f, tt.multiple)) > 1e-9 {
+				t.Fatalf("%s generated %v", tt.schema, v)
+			}
+		}
+	}
+}
+
+func TestGenerateIntegerLargeBounds(t *testing.T) {
+	opts := &Options{Rand: rand.New(rand.NewSource(1))}
+	for _, s := range []string{
+		`{"type":"integer","minimum":-1e300,"maximum":1e300}`,
+		`{"type":"integer","minimum":9.2e18,"maximum":1e19}`,
+		`{"type":"integer","minimum":-1e19,"maximum":-9.2e18}`,
+	} {
+		schema := schemaFromJson(t, s)
+		for i := 0; i < 50; i++ {
+			v, err := Generate(schema, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n := float64(v.(int64))
+			if n < *schema.Minimum || n > *schema.Maximum {
+				t.Fatalf("%s generated %v", s, v)
+			}
+		}
+	}
+}
+
+func TestGenerateIntegerFractionalMultipleOf(t *testing.T) {
+	opts := &Options{Rand: rand.New(rand.NewSource(1))}
+	for _, tt := range []struct {
+		schema string
+		step   int64
+	}{
+		{`{"type":"integer","multipleOf":2.5,"minimum":1,"maximum":20}`, 5},
+		{`{"type":"integer","multipleOf":0.5,"minimum":-3,"maximum":3}`, 1},
+		{`{"type":"integer","multipleOf":0.1,"minimum":0,"maximum":3}`, 1},
+		{`{"type":"integer","multipleOf":1.2,"minimum":0,"maximum":100}`, 6},
+	} {
+		schema := schemaFromJson(t, tt.schema)
+		for i := 0; i < 100; i++ {
+			v, err := Generate(schema, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n := v.(int64)
+			if float64(n) < *schema.Minimum || float64(n) > *schema.Maximum || n%tt.step != 0 {
+				t.Fatalf("%s generated %v", tt.schema, v)
+			}
+		}
+	}
+}
+
+func TestGenerateUnsatisfiable(t *testing.T) {
+	for _, s := range []string{
+		`{"type":"integer","minimum":5,"maximum":4}`,
+		`{"type":"integer","minimum":1,"maximum":2,"exclusiveMinimum":true,"exclusiveMaximum":true}`,
+		`{"type":"integer","minimum":1,"maximum":4,"multipleOf":5}`,
+		`{"type":"integer","minimum":1,"maximum":4,"multipleOf":2.5}`,
+		`{"type":"number","minimum":1,"maximum":1,"exclusiveMaximum":true}`,
+		`{"type":"number","minimum":0.1,"maximum":0.2,"multipleOf":0.5}`,
+		`{"type":"object","properties":{"n":{"type":"integer","minimum":3,"maximum":2}}}`,
+	} {
+		if v, err := Generate(schemaFromJson(t, s), nil); err == nil {
+			t.Errorf("%s generated %v, expected an error", s, v)
+		}
+	}
+}
+
+func definitionsFromJson(t *testing.T, s string) spec.Definitions {
+	t.Helper()
+	defs := spec.Definitions{}
+	if err := json.Unmarshal([]byte(s), &defs); err != nil {
+		t.Fatalf("bad definitions %s: %v", s, err)
+	}
+	return defs
+}
+
+const testDefinitions = `{
+  "Pet": {
+    "type": "object",
+    "required": ["name", "owner"],
+    "properties": {
+      "id": {"type": "integer", "readOnly": true},
+      "name": {"type": "string"},
+      "tag": {"type": "string"},
+      "owner": {"$ref": "#/definitions/Person"}
+    }
+  },
+  "Person": {"type": "object", "required": ["email"], "properties": {"email": {"type": "string", "format": "email"}}},
+  "Audited": {"type": "object", "required": ["created"], "properties": {"created": {"type": "string", "format": "date"}}},
+  "Category": {
+    "type": "object",
+    "required": ["name", "children"],
+    "properties": {
+      "name": {"type": "string"},
+      "children": {"type": "array", "minItems": 1, "items": {"$ref": "#/definitions/Category"}}
+    }
+  }
+}`
+
+func TestGenerateObject(t *testing.T) {
+	defs := definitionsFromJson(t, testDefinitions)
+	opts := &Options{Definitions: defs, Rand: rand.New(rand.NewSource(1))}
+
+	pet, err := GenerateObject(schemaFromJson(t, `{"$ref":"#/definitions/Pet"}`), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pet["id"]; ok {
+		t.Errorf("read only property generated: %v", pet)
+	}
+	if _, ok := pet["name"].(string); !ok {
+		t.Errorf("no name: %v", pet)
+	}
+	owner, ok := pet["owner"].(map[string]interface{})
+	if !ok || !strings.HasSuffix(owner["email"].(string), "@example.com") {
+		t.Errorf("owner reference not resolved: %v", pet)
+	}
+	if _, ok := pet["tag"]; !ok {
+		t.Errorf("optional property not generated: %v", pet)
+	}
+}
+
+func TestGenerateObjectRequiredOnly(t *testing.T) {
+	defs := definitionsFromJson(t, testDefinitions)
+	opts := &Options{Definitions: defs, RequiredOnly: true, Rand: rand.New(rand.NewSource(1))}
+	pet, err := GenerateObject(schemaFromJson(t, `{"$ref":"#/definitions/Pet"}`), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pet) != 2 || pet["name"] == nil || pet["owner"] == nil {
+		t.Errorf("expected only name and owner: %v", pet)
+	}
+}
+
+func TestGenerateObjectAllOf(t *testing.T) {
+	defs := definitionsFromJson(t, testDefinitions)
+	opts := &Options{Definitions: defs, RequiredOnly: true, Rand: rand.New(rand.NewSource(1))}
+	schema := schemaFromJson(t, `{"allOf":[{"$ref":"#/definitions/Person"},{"$ref":"#/definitions/Audited"}],
+		"required":["level"],"properties":{"level":{"type":"integer","minimum":1,"maximum":3}}}`)
+	obj, err := GenerateObject(schema, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(obj) != 3 || obj["email"] == nil || obj["created"] == nil || obj["level"] == nil {
+		t.Errorf("allOf parts not merged: %v", obj)
+	}
+}
+
+func TestGenerateObjectMaxDepth(t *testing.T) {
+	defs := definitionsFromJson(t, testDefinitions)
+	opts := &Options{Definitions: defs, MaxDepth: 4, Rand: rand.New(rand.NewSource(1))}
+	category, err := GenerateObject(schemaFromJson(t, `{"$ref":"#/definitions/Category"}`), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each level of categories is an object and an array, the fourth one is cut off.
+	depth := 0
+	var walk func(v interface{}, d int)
+	walk = func(v interface{}, d int) {
+		if d > depth {
+			depth = d
+		}
+		switch node := v.(type) {
+		case map[string]interface{}:
+			for _, child := range node {
+				walk(child, d+1)
+			}
+		case []interface{}:
+			for _, child := range node {
+				walk(child, d+1)
+			}
+		}
+	}
+	walk(category, 0)
+	if depth != 4 {
+		t.Errorf("generated %d levels, want 4: %v", depth, category)
+	}
+	children := category["children"].([]interface{})
+	grandChildren := children[0].(map[string]interface{})["children"].([]interface{})
+	if cut := grandChildren[0].(map[string]interface{}); len(cut) != 0 {
+		t.Errorf("expected an empty object at the maximum depth, got %v", cut)
+	}
+}
+
+func TestGenerateObjectSeeded(t *testing.T) {
+	defs := definitionsFromJson(t, testDefinitions)
+	schema := schemaFromJson(t, `{"$ref":"#/definitions/Category"}`)
+	generate := func() string {
+		obj, err := GenerateObject(schema, &Options{Definitions: defs, Rand: rand.New(rand.NewSource(42))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(obj)
+		return string(b)
+	}
+	if first, second := generate(), generate(); first != second {
+		t.Errorf("same seed generated different objects:\n%s\n%s", first, second)
+	}
+}
+
+func TestGenerateObjectErrors(t *testing.T) {
+	for _, s := range []string{
+		`{"type":"string"}`,
+		`{"$ref":"#/definitions/Missing"}`,
+		`{"$ref":"#/parameters/Pet"}`,
+	} {
+		if v, err := GenerateObject(schemaFromJson(t, s), nil); err == nil {
+			t.Errorf("%s generated %v, expected an error", s, v)
+		}
+	}
+}
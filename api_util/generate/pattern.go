@@ -0,0 +1,121 @@
+package generate
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+
+	mqutil "github.com/mmanjoura/vmie-api-qa/api_util"
+)
+
+// How many strings generatePattern tries before giving up on the length bounds
+const patternAttempts = 100
+
+// generatePattern returns a random string that matches the regular expression, with between min and
+// max characters. Repetitions without an upper bound, like * and +, are capped so that the strings
+// stay short, but long enough to reach min.
+func (g *generator) generatePattern(pattern string, min int, max int) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", mqutil.NewError(mqutil.ErrInvalid, fmt.Sprintf("invalid pattern %s: %s", pattern, err.Error()))
+	}
+	extra := defaultMaxLength + min
+	for i := 0; i < patternAttempts; i++ {
+		var b strings.Builder
+		if err := g.writeRegexp(&b, re, extra); err != nil {
+			return "", mqutil.NewError(mqutil.ErrInvalid, fmt.Sprintf("pattern %s: %s", pattern, err.Error()))
+		}
+		if n := utf8.RuneCountInString(b.String()); n >= min && n <= max {
+			return b.String(), nil
+		}
+	}
+	return "", unsatisfiable(fmt.Sprintf("no string of %d to %d characters found for pattern %s", min, max, pattern))
+}
+
+// writeRegexp writes a random match of re. extra is the most times an unbounded repetition goes past
+// its minimum. Anchors and word boundaries write nothing: the match is the whole string.
+func (g *generator) writeRegexp(b *strings.Builder, re *syntax.Regexp, extra int) error {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return mqutil.NewError(mqutil.ErrInvalid, "matches nothing")
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			b.WriteRune(r)
+		}
+	case syntax.OpCharClass:
+		r, ok := g.classRune(re.Rune)
+		if !ok {
+			return mqutil.NewError(mqutil.ErrInvalid, "empty character class")
+		}
+		b.WriteRune(r)
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteByte(letters[g.rand.Intn(len(letters))])
+	case syntax.OpCapture:
+		return g.writeRegexp(b, re.Sub[0], extra)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if err := g.writeRegexp(b, sub, extra); err != nil {
+				return err
+			}
+		}
+	case syntax.OpAlternate:
+		return g.writeRegexp(b, re.Sub[g.rand.Intn(len(re.Sub))], extra)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			min, max = 0, -1
+		case syntax.OpPlus:
+			min, max = 1, -1
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		if max < 0 {
+			max = min + extra
+		}
+		for n := min + g.rand.Intn(max-min+1); n > 0; n-- {
+			if err := g.writeRegexp(b, re.Sub[0], extra); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// classRune returns a random rune from the ranges of a character class, given as pairs of bounds.
+// Printable ascii runes are preferred, negated classes would otherwise mostly give rare unicode.
+func (g *generator) classRune(ranges []rune) (rune, bool) {
+	var printable []rune
+	for i := 0; i+1 < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		if lo < ' ' {
+			lo = ' '
+		}
+		if hi > '~' {
+			hi = '~'
+		}
+		if lo <= hi {
+			printable = append(printable, lo, hi)
+		}
+	}
+	if len(printable) > 0 {
+		ranges = printable
+	}
+	total := 0
+	for i := 0; i+1 < len(ranges); i += 2 {
+		total += int(ranges[i+1]-ranges[i]) + 1
+	}
+	if total == 0 {
+		return 0, false
+	}
+	n := rune(g.rand.Intn(total))
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if size := ranges[i+1] - ranges[i] + 1; n >= size {
+			n -= size
+			continue
+		}
+		return ranges[i] + n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,50 @@
+package generate
+
+import (
+	"math/rand"
+	"regexp"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestGeneratePattern(t *testing.T) {
+	opts := &Options{Rand: rand.New(rand.NewSource(1))}
+	for _, tt := range []struct {
+		schema   string
+		min, max int
+	}{
+		{`{"type":"string","pattern":"^[0-9]+$"}`, 1, 100},
+		{`{"type":"string","pattern":"^[A-Z]{3}-\\d{2,4}$"}`, 6, 8},
+		{`{"type":"string","pattern":"^(red|green|blue)?$"}`, 0, 5},
+		{`{"type":"string","pattern":"^[^a-z]x.$"}`, 3, 3},
+		{`{"type":"string","pattern":"abc"}`, 3, 3},
+		{`{"type":"string","pattern":"^\\w+@\\w+\\.com$","format":"email"}`, 7, 100},
+		{`{"type":"string","pattern":"^[a-z]*$","minLength":20,"maxLength":22}`, 20, 22},
+		{`{"type":"string","pattern":"^[a-z]*$","maxLength":0}`, 0, 0},
+	} {
+		schema := schemaFromJson(t, tt.schema)
+		re := regexp.MustCompile(schema.Pattern)
+		for i := 0; i < 50; i++ {
+			v, err := Generate(schema, opts)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.schema, err)
+			}
+			s := v.(string)
+			if n := utf8.RuneCountInString(s); !re.MatchString(s) || n < tt.min || n > tt.max {
+				t.Fatalf("%s generated %q", tt.schema, s)
+			}
+		}
+	}
+}
+
+func TestGeneratePatternErrors(t *testing.T) {
+	for _, s := range []string{
+		`{"type":"string","pattern":"^[0-9+$"}`,
+		`{"type":"string","pattern":"^[0-9]{3}$","maxLength":2}`,
+		`{"type":"string","pattern":"^[^\\x00-\\x{10FFFF}]$"}`,
+	} {
+		if v, err := Generate(schemaFromJson(t, s), nil); err == nil {
+			t.Errorf("%s generated %v, expected an error", s, v)
+		}
+	}
+}
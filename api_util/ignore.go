@@ -0,0 +1,104 @@
+package api_util
+
+import (
+	"path"
+	"strings"
+)
+
+// IgnoreRule describes fields that are skipped when comparing values, e.g. timestamps and request ids
+// that are different on every response. Rules are written as
+//   - "$.meta.requestId", "$.items[*].id": a path from the root of the value
+//   - "**.updatedAt": "**" matches any number of fields (or array entries), "*" matches exactly one
+//   - "updatedAt": a bare field name, the same as "**.updatedAt"
+//
+// Every field name can be a glob, e.g. "**.*At".
+type IgnoreRule struct {
+	Rule     string
+	segments []string
+}
+
+// The rules applied by InterfaceEquals.
+var ignoreRules []*IgnoreRule
+
+// NewIgnoreRule parses the rule.
+func NewIgnoreRule(rule string) (*IgnoreRule, error) {
+	str := strings.TrimSpace(rule)
+	anchored := strings.HasPrefix(str, "$")
+	str = strings.TrimPrefix(str, "$")
+	// Array indices are just another path segment.
+	str = strings.ReplaceAll(str, "[", ".")
+	str = strings.ReplaceAll(str, "]", "")
+	var segments []string
+	for _, s := range strings.Split(str, ".") {
+		if len(s) == 0 {
+			continue
+		}
+		if _, err := path.Match(s, ""); err != nil {
+			return nil, NewError(ErrInvalid, "invalid ignore rule "+rule+": "+err.Error())
+		}
+		segments = append(segments, s)
+	}
+	if len(segments) == 0 {
+		return nil, NewError(ErrInvalid, "empty ignore rule: "+rule)
+	}
+	if !anchored && len(segments) == 1 && segments[0] != "**" {
+		segments = append([]string{"**"}, segments...)
+	}
+	return &IgnoreRule{rule, segments}, nil
+}
+
+// Matches returns whether the rule covers the field at the path. Array entries are represented by
+// their index in the path.
+func (r *IgnoreRule) Matches(fieldPath []string) bool {
+	return matchSegments(r.segments, fieldPath)
+}
+
+func matchSegments(patterns []string, fieldPath []string) bool {
+	if len(patterns) == 0 {
+		return len(fieldPath) == 0
+	}
+	if patterns[0] == "**" {
+		for i := 0; i <= len(fieldPath); i++ {
+			if matchSegments(patterns[1:], fieldPath[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(fieldPath) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(patterns[0], fieldPath[0]); !matched {
+		return false
+	}
+	return matchSegments(patterns[1:], fieldPath[1:])
+}
+
+// SetIgnoreRules replaces the rules applied during all comparisons done by InterfaceEquals.
+func SetIgnoreRules(rules []string) error {
+	var parsed []*IgnoreRule
+	for _, rule := range rules {
+		r, err := NewIgnoreRule(rule)
+		if err != nil {
+			return err
+		}
+		parsed = append(parsed, r)
+	}
+	ignoreRules = parsed
+	return nil
+}
+
+// IsIgnoredPath returns whether any of the configured rules covers the field at the path.
+func IsIgnoredPath(fieldPath []string) bool {
+	for _, r := range ignoreRules {
+		if r.Matches(fieldPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendPath returns a new path with the field appended, leaving the original path untouched.
+func appendPath(fieldPath []string, field string) []string {
+	return append(fieldPath[:len(fieldPath):len(fieldPath)], field)
+}
@@ -0,0 +1,101 @@
+package api_util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIgnoreRuleMatches(t *testing.T) {
+	tests := []struct {
+		rule  string
+		path  string // dot separated, empty for the root
+		match bool
+	}{
+		{"updatedAt", "updatedAt", true},
+		{"updatedAt", "items.0.updatedAt", true},
+		{"updatedAt", "items.0.updatedAtX", false},
+		{"updatedAt", "updatedAt.value", false},
+
+		{"**.id", "id", true},
+		{"**.id", "a.b.c.id", true},
+		{"a.**", "a", true},
+		{"a.**", "a.b.c", true},
+		{"a.**", "b.a", false},
+		{"**.meta.**.id", "x.meta.y.z.id", true},
+
+		{"*.id", "a.id", true},
+		{"*.id", "id", false},
+		{"*.id", "a.b.id", false},
+		{"**.*At", "user.createdAt", true},
+		{"**.*At", "user.created", false},
+		{"**.item?", "items", true},
+
+		{"$.meta.requestId", "meta.requestId", true},
+		{"$.meta.requestId", "data.meta.requestId", false},
+		{"$.a[*].b", "a.0.b", true},
+		{"$.a[*].b", "a.12.b", true},
+		{"$.a[*].b", "a.0.c", false},
+		{"$.a[*].b", "a.0.x.b", false},
+		{"$.a[1].b", "a.1.b", true},
+		{"$.a[1].b", "a.0.b", false},
+		{"$.id", "id", true},
+		{"$.id", "x.id", false},
+	}
+	for _, tt := range tests {
+		r, err := NewIgnoreRule(tt.rule)
+		if err != nil {
+			t.Fatalf("NewIgnoreRule(%s) returned %v", tt.rule, err)
+		}
+		var fieldPath []string
+		if len(tt.path) > 0 {
+			fieldPath = strings.Split(tt.path, ".")
+		}
+		if got := r.Matches(fieldPath); got != tt.match {
+			t.Errorf("%s matches %s = %v, want %v", tt.rule, tt.path, got, tt.match)
+		}
+	}
+}
+
+func TestNewIgnoreRuleErrors(t *testing.T) {
+	for _, rule := range []string{"", "  ", "$", "$.", "..", `a\`, `$.items[*].na\`} {
+		if r, err := NewIgnoreRule(rule); err == nil {
+			t.Errorf("NewIgnoreRule(%q) = %v, expected an error", rule, r.segments)
+		}
+	}
+	defer SetIgnoreRules(nil)
+	if err := SetIgnoreRules([]string{"id", `a\`}); err == nil {
+		t.Error("SetIgnoreRules accepted an invalid rule")
+	}
+}
+
+func TestInterfaceEqualsIgnoreRules(t *testing.T) {
+	defer SetIgnoreRules(nil)
+	criteria := decodeJson(t, `{"id":1,"meta":{"requestId":"a","page":1},"items":[{"id":5,"updatedAt":"x","name":"n"}]}`)
+	existing := decodeJson(t, `{"id":2,"meta":{"requestId":"b","page":1},"items":[{"id":6,"updatedAt":"y","name":"n"}]}`)
+
+	if err := SetIgnoreRules(nil); err != nil {
+		t.Fatal(err)
+	}
+	if InterfaceEquals(criteria, existing) {
+		t.Error("InterfaceEquals without rules accepted different values")
+	}
+	if IsIgnoredPath([]string{"id"}) {
+		t.Error("IsIgnoredPath without rules ignored a path")
+	}
+
+	if err := SetIgnoreRules([]string{"id", "$.meta.requestId", "$.items[*].updatedAt"}); err != nil {
+		t.Fatal(err)
+	}
+	if !InterfaceEquals(criteria, existing) {
+		t.Error("InterfaceEquals didn't ignore the fields covered by the rules")
+	}
+	// Only the covered fields are ignored
+	existing.(map[string]interface{})["meta"].(map[string]interface{})["page"] = 2.0
+	if InterfaceEquals(criteria, existing) {
+		t.Error("InterfaceEquals ignored a field that no rule covers")
+	}
+	// The whole value is never ignored
+	if InterfaceEquals(decodeJson(t, `1`), decodeJson(t, `2`)) {
+		t.Error("InterfaceEquals ignored the root value")
+	}
+}
@@ -0,0 +1,194 @@
+package api_util
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// This file implements the subset of JSONPath needed by assertions, variable capture and masking:
+//   $            the root
+//   .name        a field, also ['name'] or ["name"]
+//   [2], [-1]    an array entry, negative indices count from the end
+//   .*, [*]      every field or array entry
+//   ..name       name at any depth, also ..* and ..[n]
+// Filters, slices and unions are not supported.
+
+// JSONPathMatch is a value selected by a JSONPath query, along with its location as a json pointer.
+type JSONPathMatch struct {
+	Pointer string
+	Value   interface{}
+}
+
+type jsonPathStepKind int
+
+const (
+	stepField jsonPathStepKind = iota
+	stepIndex
+	stepWildcard
+)
+
+type jsonPathStep struct {
+	kind      jsonPathStepKind
+	field     string
+	index     int
+	recursive bool // the step applies to the current nodes and all their descendants
+}
+
+// parseJSONPath parses the expression into steps.
+func parseJSONPath(expr string) ([]jsonPathStep, error) {
+	invalid := func(reason string) error {
+		return NewError(ErrInvalid, fmt.Sprintf("invalid JSONPath %s: %s", expr, reason))
+	}
+	if !strings.HasPrefix(expr, "$") {
+		return nil, invalid("must start with $")
+	}
+	var steps []jsonPathStep
+	s := expr[1:]
+	for len(s) > 0 {
+		step := jsonPathStep{}
+		switch {
+		case strings.HasPrefix(s, ".."):
+			step.recursive = true
+			s = s[2:]
+			if strings.HasPrefix(s, "[") {
+				break
+			}
+			fallthrough
+		case s[0] == '.':
+			s = strings.TrimPrefix(s, ".")
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			name := s[:end]
+			s = s[end:]
+			if len(name) == 0 {
+				return nil, invalid("empty field name")
+			}
+			if name == "*" {
+				step.kind = stepWildcard
+			} else {
+				step.kind = stepField
+				step.field = name
+			}
+			steps = append(steps, step)
+			continue
+		case s[0] != '[':
+			return nil, invalid("unexpected " + s)
+		}
+
+		// bracket
+		end := strings.Index(s, "]")
+		if end < 0 {
+			return nil, invalid("missing ]")
+		}
+		content := strings.TrimSpace(s[1:end])
+		s = s[end+1:]
+		switch {
+		case content == "*":
+			step.kind = stepWildcard
+		case len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0]:
+			step.kind = stepField
+			step.field = content[1 : len(content)-1]
+		default:
+			i, err := strconv.Atoi(content)
+			if err != nil {
+				return nil, invalid("unsupported selector [" + content + "]")
+			}
+			step.kind = stepIndex
+			step.index = i
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// descendants returns the match and everything below it, parents before children.
+func descendants(m JSONPathMatch) []JSONPathMatch {
+	all := []JSONPathMatch{m}
+	for _, child := range children(m) {
+		all = append(all, descendants(child)...)
+	}
+	return all
+}
+
+// children returns the fields of a map, in key order, or the entries of an array.
+func children(m JSONPathMatch) []JSONPathMatch {
+	var res []JSONPathMatch
+	switch node := m.Value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(node))
+		for k := range node {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			res = append(res, JSONPathMatch{m.Pointer + "/" + EscapePointerToken(k), node[k]})
+		}
+	case []interface{}:
+		for i, v := range node {
+			res = append(res, JSONPathMatch{m.Pointer + "/" + strconv.Itoa(i), v})
+		}
+	}
+	return res
+}
+
+// applyStep selects what the step refers to from a single node.
+func applyStep(m JSONPathMatch, step jsonPathStep) []JSONPathMatch {
+	switch step.kind {
+	case stepWildcard:
+		return children(m)
+	case stepField:
+		if node, ok := m.Value.(map[string]interface{}); ok {
+			if v, ok := node[step.field]; ok {
+				return []JSONPathMatch{{m.Pointer + "/" + EscapePointerToken(step.field), v}}
+			}
+		}
+	case stepIndex:
+		if node, ok := m.Value.([]interface{}); ok {
+			i := step.index
+			if i < 0 {
+				i += len(node)
+			}
+			if i >= 0 && i < len(node) {
+				return []JSONPathMatch{{m.Pointer + "/" + strconv.Itoa(i), node[i]}}
+			}
+		}
+	}
+	return nil
+}
+
+// QueryJSONPath returns everything the JSONPath expression selects in doc, in document order. No
+// match is not an error; an invalid expression is.
+func QueryJSONPath(doc interface{}, expr string) ([]JSONPathMatch, error) {
+	steps, err := parseJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	current := []JSONPathMatch{{"", doc}}
+	for _, step := range steps {
+		var next []JSONPathMatch
+		for _, m := range current {
+			candidates := []JSONPathMatch{m}
+			if step.recursive {
+				candidates = descendants(m)
+			}
+			for _, c := range candidates {
+				next = append(next, applyStep(c, step)...)
+			}
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// GetByJSONPath returns the first value the expression selects. ok is false if nothing matches.
+func GetByJSONPath(doc interface{}, expr string) (value interface{}, ok bool, err error) {
+	matches, err := QueryJSONPath(doc, expr)
+	if err != nil || len(matches) == 0 {
+		return nil, false, err
+	}
+	return matches[0].Value, true, nil
+}
@@ -0,0 +1,94 @@
+package api_util
+
+import (
+	"reflect"
+	"testing"
+)
+
+const jsonPathDoc = `{
+	"store": {
+		"book": [
+			{"title": "A", "price": 8, "tags": ["x"]},
+			{"title": "B", "price": 12},
+			{"title": "C", "price": 9}
+		],
+		"bike": {"color": "red", "price": 100}
+	},
+	"a.b": 1,
+	"a/b": 2
+}`
+
+func TestQueryJSONPath(t *testing.T) {
+	tests := []struct {
+		expr     string
+		pointers []string
+	}{
+		{"$", []string{""}},
+		{"$.store.bike.color", []string{"/store/bike/color"}},
+		{"$['store']['bike']", []string{"/store/bike"}},
+		{`$["a.b"]`, []string{"/a.b"}},
+		{"$['a/b']", []string{"/a~1b"}},
+		{"$.store.book[0].title", []string{"/store/book/0/title"}},
+		{"$.store.book[-1].title", []string{"/store/book/2/title"}},
+		{"$.store.book[3]", nil},
+		{"$.store.book[-4]", nil},
+		{"$.store.book[*].title", []string{"/store/book/0/title", "/store/book/1/title", "/store/book/2/title"}},
+		{"$.store.bike.*", []string{"/store/bike/color", "/store/bike/price"}},
+		{"$..price", []string{"/store/bike/price", "/store/book/0/price", "/store/book/1/price", "/store/book/2/price"}},
+		{"$..book[1].title", []string{"/store/book/1/title"}},
+		{"$..[0]", []string{"/store/book/0", "/store/book/0/tags/0"}},
+		{"$.store..tags[*]", []string{"/store/book/0/tags/0"}},
+		{"$.missing.field", nil},
+	}
+	doc := decodeJson(t, jsonPathDoc)
+	for _, tt := range tests {
+		matches, err := QueryJSONPath(doc, tt.expr)
+		if err != nil {
+			t.Errorf("QueryJSONPath(%s) returned %v", tt.expr, err)
+			continue
+		}
+		var pointers []string
+		for _, m := range matches {
+			pointers = append(pointers, m.Pointer)
+			if v, err := GetByPointer(doc, m.Pointer); err != nil || !reflect.DeepEqual(v, m.Value) {
+				t.Errorf("QueryJSONPath(%s): value at %s doesn't match its pointer", tt.expr, m.Pointer)
+			}
+		}
+		if !reflect.DeepEqual(pointers, tt.pointers) {
+			t.Errorf("QueryJSONPath(%s) = %q, want %q", tt.expr, pointers, tt.pointers)
+		}
+	}
+}
+
+func TestQueryJSONPathRejected(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"store.book",
+		"$.",
+		"$.store..",
+		"$.store.book[",
+		"$.store.book[?(@.price > 10)]",
+		"$.store.book[0:2]",
+		"$.store.book[0,1]",
+		"$.store.book[abc]",
+		"$store",
+	} {
+		if _, err := QueryJSONPath(map[string]interface{}{}, expr); err == nil {
+			t.Errorf("QueryJSONPath(%q) should be rejected", expr)
+		}
+	}
+}
+
+func TestGetByJSONPath(t *testing.T) {
+	doc := decodeJson(t, jsonPathDoc)
+	v, ok, err := GetByJSONPath(doc, "$..title")
+	if err != nil || !ok || v != "A" {
+		t.Errorf("GetByJSONPath($..title) = %v, %v, %v", v, ok, err)
+	}
+	if _, ok, err := GetByJSONPath(doc, "$.nope"); ok || err != nil {
+		t.Errorf("GetByJSONPath($.nope) = %v, %v", ok, err)
+	}
+	if _, _, err := GetByJSONPath(doc, "nope"); err == nil {
+		t.Error("GetByJSONPath(nope) should fail")
+	}
+}
@@ -0,0 +1,156 @@
+package api_util
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits for the per-run log files
+var (
+	LogMaxSize    int64 = 10 * 1024 * 1024 // a log file is rotated when it grows past this size
+	LogMaxBackups       = 3                // rotated files kept for each run, the oldest is dropped
+	LogMaxRuns          = 20               // run logs kept in the log directory, older runs are deleted
+)
+
+// The directory, under the meqa data directory, that holds the run logs.
+const LogDir = "logs"
+
+// RotatingFile is an io.Writer on a file that is rotated when it gets too big: path becomes path.1,
+// path.1 becomes path.2 and so on. It is safe to use from multiple goroutines.
+type RotatingFile struct {
+	Path       string
+	MaxSize    int64
+	MaxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewRotatingFile opens the file at path for appending, creating it and its directory if needed.
+func NewRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	r := &RotatingFile{Path: path, MaxSize: maxSize, MaxBackups: maxBackups}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file = f
+	r.size = fi.Size()
+	return nil
+}
+
+// rotate closes the current file, shifts the backups and starts a new file.
+func (r *RotatingFile) rotate() error {
+	r.file.Close()
+	r.file = nil
+	if r.MaxBackups <= 0 {
+		os.Remove(r.Path)
+	} else {
+		os.Remove(fmt.Sprintf("%s.%d", r.Path, r.MaxBackups))
+		for i := r.MaxBackups - 1; i > 0; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", r.Path, i), fmt.Sprintf("%s.%d", r.Path, i+1))
+		}
+		os.Rename(r.Path, r.Path+".1")
+	}
+	return r.open()
+}
+
+// Write writes p to the file, rotating it first if p would take it past MaxSize.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return 0, NewError(ErrInvalid, "write to a closed log file "+r.Path)
+	}
+	if r.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.MaxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// NewRunLogFile creates the log file of a new run in the logs directory under meqaPath. The file is
+// named after the start time of the run, so concurrent runs don't write to the same file. The logs of
+// old runs beyond LogMaxRuns are deleted.
+func NewRunLogFile(meqaPath string) (*RotatingFile, error) {
+	dir := filepath.Join(meqaPath, LogDir)
+	name := fmt.Sprintf("run-%s-%d.log", time.Now().Format("20060102-150405.000000"), os.Getpid())
+	r, err := NewRotatingFile(filepath.Join(dir, name), LogMaxSize, LogMaxBackups)
+	if err != nil {
+		return nil, err
+	}
+	pruneRunLogs(dir, LogMaxRuns)
+	return r, nil
+}
+
+// pruneRunLogs deletes the oldest run logs, with their backups, keeping the last maxRuns. The names
+// start with the time, so they sort in creation order. Only regular files are deleted.
+func pruneRunLogs(dir string, maxRuns int) {
+	matches, err := filepath.Glob(filepath.Join(dir, "run-*.log"))
+	if err != nil || maxRuns <= 0 {
+		return
+	}
+	var logs []string
+	for _, f := range matches {
+		if fi, err := os.Lstat(f); err == nil && fi.Mode().IsRegular() {
+			logs = append(logs, f)
+		}
+	}
+	if len(logs) <= maxRuns {
+		return
+	}
+	sort.Strings(logs)
+	for _, old := range logs[:len(logs)-maxRuns] {
+		backups, _ := filepath.Glob(old + ".*")
+		for _, f := range append(backups, old) {
+			if f == old || strings.TrimLeft(strings.TrimPrefix(f, old+"."), "0123456789") == "" {
+				if fi, err := os.Lstat(f); err == nil && fi.Mode().IsRegular() {
+					os.Remove(f)
+				}
+			}
+		}
+	}
+}
+
+// NewRunLogger returns a logger writing to out, with the same format as NewLogger. Unlike NewLogger
+// it leaves the global Logger alone, so each run can carry its own.
+func NewRunLogger(out io.Writer) *log.Logger {
+	return log.New(out, "", (log.Ldate | log.Lmicroseconds | log.Lshortfile))
+}
@@ -0,0 +1,155 @@
+package api_util
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// readDir returns the names of the files in dir, sorted.
+func readDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "run.log")
+	r, err := NewRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// A write bigger than MaxSize still goes into an empty file
+	for _, s := range []string{"aaaaaaaaaaaa", "bbbbbb", "cccccc", "dd", "eeeeee"} {
+		if n, err := r.Write([]byte(s)); err != nil || n != len(s) {
+			t.Fatalf("Write(%s) = %d, %v", s, n, err)
+		}
+	}
+	if got, want := readDir(t, filepath.Dir(path)), []string{"run.log", "run.log.1", "run.log.2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("files %v, want %v", got, want)
+	}
+	for name, want := range map[string]string{"run.log": "eeeeee", "run.log.1": "ccccccdd", "run.log.2": "bbbbbb"} {
+		if got := readFile(t, filepath.Join(filepath.Dir(path), name)); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	r.Close()
+	if _, err := r.Write([]byte("x")); err == nil {
+		t.Error("Write on a closed file succeeded")
+	}
+}
+
+func TestRotatingFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.log")
+	if err := os.WriteFile(path, []byte("12345678"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRotatingFile(path, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.Write([]byte("ab"))
+	if got := readFile(t, path); got != "12345678ab" {
+		t.Errorf("existing file not appended to: %q", got)
+	}
+	// Without backups the full file is dropped
+	r.Write([]byte("cd"))
+	if got, want := readDir(t, filepath.Dir(path)), []string{"run.log"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("files %v, want %v", got, want)
+	}
+	if got := readFile(t, path); got != "cd" {
+		t.Errorf("run.log = %q, want cd", got)
+	}
+}
+
+func TestPruneRunLogs(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"run-20260101-000000.000000-1.log", "run-20260101-000000.000000-1.log.1", "run-20260101-000000.000000-1.log.2",
+		"run-20260101-000000.000000-1.log.bak", "run-20260101-000000.000000-1.log.1x",
+		"run-20260102-000000.000000-2.log", "run-20260102-000000.000000-2.log.1",
+		"run-20260103-000000.000000-3.log",
+		"notes.txt", "other.log", "run-20260101.txt",
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Directories are left alone, even when their name looks like a run log or a backup
+	for _, d := range []string{"run-20260100-dir.log", "run-20260101-000000.000000-1.log.3"} {
+		if err := os.Mkdir(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pruneRunLogs(dir, 2)
+	want := []string{
+		"notes.txt", "other.log",
+		"run-20260100-dir.log",
+		"run-20260101-000000.000000-1.log.1x", "run-20260101-000000.000000-1.log.3", "run-20260101-000000.000000-1.log.bak",
+		"run-20260101.txt",
+		"run-20260102-000000.000000-2.log", "run-20260102-000000.000000-2.log.1",
+		"run-20260103-000000.000000-3.log",
+	}
+	if got := readDir(t, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("after pruning %v\nwant %v", got, want)
+	}
+
+	// Nothing to prune
+	pruneRunLogs(dir, 2)
+	pruneRunLogs(dir, 0)
+	if got := readDir(t, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("pruning again removed files: %v", got)
+	}
+}
+
+func TestNewRunLogFile(t *testing.T) {
+	defer func(maxRuns int) { LogMaxRuns = maxRuns }(LogMaxRuns)
+	LogMaxRuns = 2
+	meqaPath := t.TempDir()
+	var paths []string
+	for i := 0; i < 3; i++ {
+		r, err := NewRunLogFile(meqaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Write([]byte("log"))
+		r.Close()
+		paths = append(paths, r.Path)
+	}
+	logs := readDir(t, filepath.Join(meqaPath, LogDir))
+	if len(logs) != 2 || logs[0] != filepath.Base(paths[1]) || logs[1] != filepath.Base(paths[2]) {
+		t.Errorf("logs %v, want the last two of %v", logs, paths)
+	}
+	for _, name := range logs {
+		if !strings.HasPrefix(name, "run-") || !strings.HasSuffix(name, ".log") {
+			t.Errorf("unexpected log name %s", name)
+		}
+	}
+}
@@ -42,7 +42,7 @@ const (
 // Returns:
 //   - *log.Logger: The created logger.
 func NewLogger(out io.Writer) *log.Logger {
-	Logger = log.New(out, "", (log.Ldate | log.Lmicroseconds | log.Lshortfile))
+	Logger = NewRunLogger(out)
 	return Logger
 }
 
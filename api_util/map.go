@@ -10,7 +10,6 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
-	"time"
 
 	"github.com/go-openapi/swag"
 	"gopkg.in/yaml.v3"
@@ -18,33 +17,50 @@ import (
 
 // InterfaceToJsonString converts the given interface{} value to a JSON string.
 // It uses the json.Marshal function to serialize the interface{} value to JSON.
-// If the resulting JSON string starts and ends with double quotes, it removes them before returning the string.
-// If an error occurs during the serialization process, an empty string is returned.
-func InterfaceToJsonString(i interface{}) string {
-	b, _ := json.Marshal(i)
-	if b[0] == '"' {
-		return string(b[1 : len(b)-1]) // remove the ""
-	}
-	return string(b)
+// Values that serialize to a JSON string (strings, times, ...) are returned as the plain string
+// content, without the quotes and escaping, so that they can be used as parameter values.
+// If the value can't be serialized, the marshaling error is returned.
+func InterfaceToJsonString(i interface{}) (string, error) {
+	b, err := json.Marshal(i)
+	if err != nil {
+		return "", err
+	}
+	if len(b) > 0 && b[0] == '"' {
+		var str string
+		err = json.Unmarshal(b, &str)
+		if err != nil {
+			return "", err
+		}
+		return str, nil
+	}
+	return string(b), nil
 }
 
 // MapInterfaceToMapString converts the params map (all primitive types with exception of array)
-// before passing to resty.
-func MapInterfaceToMapString(src map[string]interface{}) map[string]string {
+// before passing to resty. It fails if any of the values can't be serialized.
+func MapInterfaceToMapString(src map[string]interface{}) (map[string]string, error) {
 	dst := make(map[string]string)
 	for k, v := range src {
 		if ar, ok := v.([]interface{}); ok {
 			str := ""
 			for _, entry := range ar {
-				str += fmt.Sprintf("%v,", InterfaceToJsonString(entry))
+				entryStr, err := InterfaceToJsonString(entry)
+				if err != nil {
+					return nil, err
+				}
+				str += fmt.Sprintf("%v,", entryStr)
 			}
 			str = strings.TrimRight(str, ",")
 			dst[k] = str
 		} else {
-			dst[k] = InterfaceToJsonString(v)
+			str, err := InterfaceToJsonString(v)
+			if err != nil {
+				return nil, err
+			}
+			dst[k] = str
 		}
 	}
-	return dst
+	return dst, nil
 }
 
 // MapIsCompatible checks if the first map has every key in the second.
@@ -58,61 +74,46 @@ func MapIsCompatible(big map[string]interface{}, small map[string]interface{}) b
 }
 
 // TimeCompare compares two values and determines if they represent the same time.
-// It takes two interface{} arguments, v1 and v2, which are expected to be strings
-// representing time in RFC3339 format. The function returns true if the values are
-// equal, otherwise it returns false.
-//
-// If either v1 or v2 is not a string or not in RFC3339 format, the function returns false.
-//
-// If both v1 and v2 are valid RFC3339 strings, the function compares the parsed time values
-// and returns true if they are equal, otherwise it returns false.
+// Each value is parsed with the layouts in TimeLayouts, tried in order, and the resulting instants
+// are compared, allowing a difference of up to TimeTolerance. Strings, json.Number and Go numbers
+// are accepted; numbers can only be unix epoch times.
 //
-// If only one of v1 and v2 is a valid RFC3339 string, the function checks if the other value
-// contains the second and minute elements of the parsed time value. If both elements are found,
-// the function returns true, otherwise it returns false.
+// If either value can't be parsed, the function returns false. Two values that only parse as epoch
+// times are plain numbers and are not considered times either. An epoch time only matches a formatted
+// time if CompareEpochTimes is set, and a date only matches a date time if DateMatchesDateTime is set.
 //
 // Example usage:
 //   t1 := "2022-01-01T12:00:00Z"
-//   t2 := "2022-01-01T12:00:00Z"
+//   t2 := "Sat, 01 Jan 2022 12:00:00 GMT"
 //   result := TimeCompare(t1, t2) // returns true
 //
 //   t3 := "2022-01-01T12:00:00Z"
 //   t4 := "2022-01-01T12:01:00Z"
 //   result := TimeCompare(t3, t4) // returns false
-
 func TimeCompare(v1 interface{}, v2 interface{}) bool {
-	s1, ok := v1.(string)
+	t1, layout1, ok := parseTimeLayout(v1)
 	if !ok {
 		return false
 	}
-	s2, ok := v2.(string)
+	t2, layout2, ok := parseTimeLayout(v2)
 	if !ok {
 		return false
 	}
-	var t time.Time
-	var s string
-	var b1, b2 bool
-	t1, err := time.Parse(time.RFC3339, s1)
-	if err == nil {
-		t = t1
-		s = s2
-		b1 = true
-	}
-	t2, err := time.Parse(time.RFC3339, s2)
-	if err == nil {
-		t = t2
-		s = s1
-		b2 = true
-	}
-	if b1 && b2 {
-		return t1 == t2
-	}
-	if !b1 && !b2 {
+	epoch1, epoch2 := isEpochLayout(layout1), isEpochLayout(layout2)
+	if epoch1 && epoch2 {
+		return false
+	}
+	if (epoch1 || epoch2) && !CompareEpochTimes {
 		return false
 	}
-	// One of b1 and b2 is true, now t point to time and s point to a potential time string
-	// that's not RFC3339 format. We make a guess buy searching for a few key elements.
-	return strings.Contains(s, fmt.Sprintf("%d", t.Second())) && strings.Contains(s, fmt.Sprintf("%d", t.Minute()))
+	if isDateLayout(layout1) != isDateLayout(layout2) && !DateMatchesDateTime {
+		return false
+	}
+	diff := t1.Sub(t2)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= TimeTolerance
 }
 
 // MapCombine combines two map together. If there is any overlap the dst will be overwritten.
@@ -222,9 +223,10 @@ func ArrayCopy(src []interface{}) (dst []interface{}) {
 // The function marshals the interface to YAML format using the `yaml.Marshal` function,
 // and then logs the YAML string to the logger using the `Logger.Print` method.
 // If the `printToConsole` flag is set to true, it also prints the YAML string to the console using `fmt.Println`.
+// Sensitive fields are masked, see Redact.
 // Note: Any error that occurs during marshaling is ignored.
 func InterfacePrint(m interface{}, printToConsole bool) {
-	yamlBytes, _ := yaml.Marshal(m)
+	yamlBytes, _ := yaml.Marshal(Redact(m))
 	Logger.Print(string(yamlBytes))
 	if printToConsole {
 		fmt.Println(string(yamlBytes))
@@ -237,9 +239,24 @@ func InterfacePrint(m interface{}, printToConsole bool) {
 // It returns true if the values are equal, and false otherwise.
 // The function handles various types of values, including nil, maps, arrays, slices, and strings.
 // For maps, it recursively compares the key-value pairs.
-// For strings, it checks if the existing value is a JSON number.
+// Numbers are compared by value whatever their Go type, see NumbersEqual.
 // For other types, it compares the values using reflection and JSON marshaling.
+// Fields covered by the ignore rules (see SetIgnoreRules) are not compared.
+// Arrays are compared according to DefaultArrayCompareMode.
 func InterfaceEquals(criteria interface{}, existing interface{}) bool {
+	return interfaceEqualsAt(criteria, existing, nil, DefaultArrayCompareMode)
+}
+
+// InterfaceEqualsMode is InterfaceEquals with arrays, at any depth, compared according to mode.
+func InterfaceEqualsMode(criteria interface{}, existing interface{}, mode ArrayCompareMode) bool {
+	return interfaceEqualsAt(criteria, existing, nil, mode)
+}
+
+// interfaceEqualsAt is InterfaceEquals for values found at fieldPath from the root of the comparison.
+func interfaceEqualsAt(criteria interface{}, existing interface{}, fieldPath []string, mode ArrayCompareMode) bool {
+	if len(ignoreRules) > 0 && len(fieldPath) > 0 && IsIgnoredPath(fieldPath) {
+		return true
+	}
 	if criteria == nil {
 		if existing == nil {
 			return true
@@ -255,6 +272,12 @@ func InterfaceEquals(criteria interface{}, existing interface{}) bool {
 			return false
 		}
 	}
+	// 1, 1.0, json.Number("1") and, against a number, "1" are the same value.
+	if c, ok, cIsString := numberValue(criteria); ok {
+		if e, ok, eIsString := numberValue(existing); ok && !(cIsString && eIsString) {
+			return c.equals(e)
+		}
+	}
 	cType := reflect.TypeOf(criteria)
 	eType := reflect.TypeOf(existing)
 	if cType == eType && cType.Comparable() {
@@ -269,8 +292,7 @@ func InterfaceEquals(criteria interface{}, existing interface{}) bool {
 	eKind := eType.Kind()
 	if cKind == reflect.Array || cKind == reflect.Slice {
 		if eKind == reflect.Array || eKind == reflect.Slice {
-			// We don't compare arrays
-			return true
+			return arrayEquals(criteria, existing, fieldPath, mode)
 		}
 		return false
 	}
@@ -287,14 +309,16 @@ func InterfaceEquals(criteria interface{}, existing interface{}) bool {
 			return false
 		}
 		for k, v := range cm {
-			if !InterfaceEquals(v, em[k]) {
+			if !interfaceEqualsAt(v, em[k], appendPath(fieldPath, k), mode) {
 				return false
 			}
 		}
 		return true
 	}
-	if eKind == reflect.String && (cKind == reflect.Int || cKind == reflect.Float32 || cKind == reflect.Float64) {
-		return reflect.TypeOf(existing).String() == "json.Number"
+	// Times may also be serialized as different types on both ends, e.g. epoch seconds and RFC3339
+	// when CompareEpochTimes is set.
+	if TimeCompare(criteria, existing) {
+		return true
 	}
 
 	cJson, _ := json.Marshal(criteria)
@@ -0,0 +1,59 @@
+package api_util
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInterfaceToJsonString(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{`a"b`, `a"b`},
+		{`back\slash <tag> & é`, `back\slash <tag> & é`},
+		{"", ""},
+		{12.5, "12.5"},
+		{true, "true"},
+		{nil, "null"},
+		{time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), "2026-01-02T03:04:05Z"},
+		{map[string]interface{}{"a": `x"y`}, `{"a":"x\"y"}`},
+		{[]interface{}{1, "b"}, `[1,"b"]`},
+	}
+	for _, tt := range tests {
+		got, err := InterfaceToJsonString(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("InterfaceToJsonString(%v) = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestInterfaceToJsonStringErrors(t *testing.T) {
+	for _, value := range []interface{}{make(chan int), math.Inf(1), math.NaN(), func() {}, map[string]interface{}{"a": math.Inf(-1)}} {
+		if got, err := InterfaceToJsonString(value); err == nil {
+			t.Errorf("InterfaceToJsonString(%v) = %q, expected an error", value, got)
+		}
+	}
+}
+
+func TestMapInterfaceToMapString(t *testing.T) {
+	got, err := MapInterfaceToMapString(map[string]interface{}{"q": `a"b`, "n": 3, "ids": []interface{}{1, "x", `y"z`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"q": `a"b`, "n": "3", "ids": `1,x,y"z`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MapInterfaceToMapString = %v, want %v", got, want)
+	}
+
+	for _, src := range []map[string]interface{}{
+		{"ok": 1, "bad": math.Inf(1)},
+		{"ok": 1, "bad": []interface{}{1, make(chan int)}},
+	} {
+		if got, err := MapInterfaceToMapString(src); err == nil {
+			t.Errorf("MapInterfaceToMapString(%v) = %v, expected an error", src, got)
+		}
+	}
+}
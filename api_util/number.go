@@ -0,0 +1,96 @@
+package api_util
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+// FloatEpsilon is the tolerance used when comparing numbers, unless both are integers, which are always
+// compared exactly. Two numbers are equal if they differ by no more than FloatEpsilon times the larger
+// of their magnitudes (or FloatEpsilon itself for magnitudes below 1). The default 0 only accepts exact
+// matches.
+var FloatEpsilon float64
+
+// NumbersEqual returns whether the two numbers are equal within FloatEpsilon.
+func NumbersEqual(a float64, b float64) bool {
+	if a == b {
+		return true
+	}
+	scale := math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+	return math.Abs(a-b) <= FloatEpsilon*scale
+}
+
+// number is a numeric value. Integers are also kept exactly, because float64 can't hold every int64
+// or uint64: large ids would compare equal to their neighbours.
+type number struct {
+	f     float64
+	exact *big.Int // nil if the value has a fractional part
+}
+
+// equals compares two integers exactly, and otherwise uses NumbersEqual.
+func (n number) equals(o number) bool {
+	if n.exact != nil && o.exact != nil {
+		return n.exact.Cmp(o.exact) == 0
+	}
+	return NumbersEqual(n.f, o.f)
+}
+
+// String returns the integer digits for integers, the shortest float form otherwise.
+func (n number) String() string {
+	if n.exact != nil {
+		return n.exact.String()
+	}
+	return strconv.FormatFloat(n.f, 'f', -1, 64)
+}
+
+// floatNumber returns the number for a float, which is exact if the float is integral.
+func floatNumber(f float64) number {
+	n := number{f: f}
+	if !math.IsInf(f, 0) && !math.IsNaN(f) && f == math.Trunc(f) {
+		n.exact, _ = big.NewFloat(f).Int(nil)
+	}
+	return n
+}
+
+// parseNumber parses the text of a json number, or any number strconv.ParseFloat accepts. Integers are
+// read digit by digit, so they keep their precision.
+func parseNumber(s string) (number, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return number{}, false
+	}
+	if i, ok := new(big.Int).SetString(s, 10); ok {
+		return number{f, i}, true
+	}
+	if err != nil {
+		return number{}, false
+	}
+	return floatNumber(f), true
+}
+
+// numberValue returns the value of any Go number, json.Number or string holding a number. isString
+// tells the value came from a plain string, so that two strings are still compared as strings.
+func numberValue(v interface{}) (n number, ok bool, isString bool) {
+	switch value := v.(type) {
+	case json.Number:
+		n, ok := parseNumber(value.String())
+		return n, ok, false
+	case string:
+		n, ok := parseNumber(value)
+		return n, ok, true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return number{float64(rv.Int()), big.NewInt(rv.Int())}, true, false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return number{float64(rv.Uint()), new(big.Int).SetUint64(rv.Uint())}, true, false
+	case reflect.Float32, reflect.Float64:
+		return floatNumber(rv.Float()), true, false
+	}
+	return number{}, false, false
+}
@@ -0,0 +1,54 @@
+package api_util
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestInterfaceEqualsNumbers(t *testing.T) {
+	tests := []struct {
+		name     string
+		criteria interface{}
+		existing interface{}
+		want     bool
+	}{
+		{"int and float", 1, 1.0, true},
+		{"int and json.Number", int64(42), json.Number("42"), true},
+		{"json.Number exponent", json.Number("1e3"), 1000, true},
+		{"number and numeric string", 7, "7", true},
+		{"two numeric strings stay strings", "1", "1.0", false},
+		{"different ints", 1, 2, false},
+		{"large int64 exact", int64(9007199254740993), int64(9007199254740992), false},
+		{"large int64 equal", int64(9007199254740993), int64(9007199254740993), true},
+		{"large json.Number exact", json.Number("9007199254740993"), json.Number("9007199254740992"), false},
+		{"large json.Number and int64", json.Number("9007199254740993"), int64(9007199254740993), true},
+		{"large uint64 exact", uint64(18446744073709551615), uint64(18446744073709551614), false},
+		{"beyond uint64", json.Number("123456789012345678901234567890"), json.Number("123456789012345678901234567891"), false},
+		{"float64 against exact int", 9007199254740992.0, int64(9007199254740993), false},
+		{"fractional", 0.1, json.Number("0.1"), true},
+		{"fractional different", 0.1, 0.2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InterfaceEquals(tt.criteria, tt.existing); got != tt.want {
+				t.Errorf("InterfaceEquals(%v, %v) = %v, want %v", tt.criteria, tt.existing, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFloatEpsilon(t *testing.T) {
+	defer func(old float64) { FloatEpsilon = old }(FloatEpsilon)
+	a, b := 0.1, 0.2 // not constants, so the sum is rounded like at run time
+	FloatEpsilon = 1e-9
+	if !InterfaceEquals(a+b, 0.3) {
+		t.Error("0.1+0.2 should equal 0.3 within FloatEpsilon")
+	}
+	if InterfaceEquals(int64(1000000000001), int64(1000000000000)) {
+		t.Error("integers must be compared exactly whatever FloatEpsilon is")
+	}
+	FloatEpsilon = 0
+	if InterfaceEquals(a+b, 0.3) {
+		t.Error("0.1+0.2 should differ from 0.3 without FloatEpsilon")
+	}
+}
@@ -0,0 +1,281 @@
+package api_util
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// JSON Patch (RFC 6902) operations
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+	PatchMove    = "move"
+	PatchCopy    = "copy"
+	PatchTest    = "test"
+)
+
+// PatchOperation is a single JSON Patch operation.
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value"`
+}
+
+// MarshalJSON writes the value only for the operations that take one, keeping null values for them.
+func (op PatchOperation) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{"op": op.Op, "path": op.Path}
+	switch op.Op {
+	case PatchAdd, PatchReplace, PatchTest:
+		m["value"] = op.Value
+	case PatchMove, PatchCopy:
+		m["from"] = op.From
+	}
+	return json.Marshal(m)
+}
+
+// jsonEqual compares two decoded json values exactly, ignoring the Go types of numbers.
+func jsonEqual(a interface{}, b interface{}) bool {
+	aJson, _ := json.Marshal(a)
+	bJson, _ := json.Marshal(b)
+	return string(aJson) == string(bJson)
+}
+
+// deepCopy copies maps and arrays at any depth. Unlike MapCopy, empty maps and arrays stay empty
+// instead of becoming nil.
+func deepCopy(v interface{}) interface{} {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(node))
+		for k, val := range node {
+			m[k] = deepCopy(val)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(node))
+		for i, val := range node {
+			a[i] = deepCopy(val)
+		}
+		return a
+	}
+	return v
+}
+
+// CreateJSONPatch returns the JSON Patch that turns original into modified. Arrays are compared
+// entry by entry, so an insertion in the middle shows up as replacements followed by an add.
+func CreateJSONPatch(original interface{}, modified interface{}) []PatchOperation {
+	return diffJSON(nil, "", original, modified)
+}
+
+func diffJSON(ops []PatchOperation, pointer string, a interface{}, b interface{}) []PatchOperation {
+	am, aIsMap := a.(map[string]interface{})
+	bm, bIsMap := b.(map[string]interface{})
+	if aIsMap && bIsMap {
+		for _, k := range sortedKeys(am) {
+			p := pointer + "/" + EscapePointerToken(k)
+			if bv, ok := bm[k]; ok {
+				ops = diffJSON(ops, p, am[k], bv)
+			} else {
+				ops = append(ops, PatchOperation{Op: PatchRemove, Path: p})
+			}
+		}
+		for _, k := range sortedKeys(bm) {
+			if _, ok := am[k]; !ok {
+				ops = append(ops, PatchOperation{Op: PatchAdd, Path: pointer + "/" + EscapePointerToken(k), Value: bm[k]})
+			}
+		}
+		return ops
+	}
+
+	aa, aIsArray := a.([]interface{})
+	ba, bIsArray := b.([]interface{})
+	if aIsArray && bIsArray {
+		common := len(aa)
+		if len(ba) < common {
+			common = len(ba)
+		}
+		for i := 0; i < common; i++ {
+			ops = diffJSON(ops, pointer+"/"+strconv.Itoa(i), aa[i], ba[i])
+		}
+		for i := common; i < len(ba); i++ {
+			ops = append(ops, PatchOperation{Op: PatchAdd, Path: pointer + "/" + strconv.Itoa(i), Value: ba[i]})
+		}
+		// Remove from the end so that the indices stay valid.
+		for i := len(aa) - 1; i >= common; i-- {
+			ops = append(ops, PatchOperation{Op: PatchRemove, Path: pointer + "/" + strconv.Itoa(i)})
+		}
+		return ops
+	}
+
+	if !jsonEqual(a, b) {
+		ops = append(ops, PatchOperation{Op: PatchReplace, Path: pointer, Value: b})
+	}
+	return ops
+}
+
+// splitPointer returns the pointer to the parent and the last token of the pointer.
+func splitPointer(pointer string) (string, string, error) {
+	tokens, err := ParsePointer(pointer)
+	if err != nil {
+		return "", "", err
+	}
+	if len(tokens) == 0 {
+		return "", "", NewError(ErrInvalid, "the root has no parent")
+	}
+	return MakePointer(tokens[:len(tokens)-1]), tokens[len(tokens)-1], nil
+}
+
+// patchAdd inserts the value as described by the JSON Patch add operation.
+func patchAdd(doc interface{}, pointer string, value interface{}) (interface{}, error) {
+	if len(pointer) == 0 {
+		return value, nil
+	}
+	parentPointer, last, err := splitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	parent, err := GetByPointer(doc, parentPointer)
+	if err != nil {
+		return nil, err
+	}
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+		return doc, nil
+	case []interface{}:
+		i := len(node)
+		if last != "-" {
+			// Adding may also append, so the index can be one past the end.
+			if i, err = arrayIndex(last, len(node)+1, pointer); err != nil {
+				return nil, err
+			}
+		}
+		a := append(node[:i:i], value)
+		a = append(a, node[i:]...)
+		return SetByPointer(doc, parentPointer, a)
+	}
+	return nil, NewError(ErrInvalid, fmt.Sprintf("json patch path %s doesn't point into an object or array", pointer))
+}
+
+// patchRemove removes the value as described by the JSON Patch remove operation.
+func patchRemove(doc interface{}, pointer string) (interface{}, error) {
+	if _, err := GetByPointer(doc, pointer); err != nil {
+		return nil, err
+	}
+	parentPointer, last, err := splitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	parent, _ := GetByPointer(doc, parentPointer)
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		delete(node, last)
+		return doc, nil
+	case []interface{}:
+		i, _ := strconv.Atoi(last)
+		a := append(node[:i:i], node[i+1:]...)
+		return SetByPointer(doc, parentPointer, a)
+	}
+	return doc, nil
+}
+
+// ApplyJSONPatch applies the operations in order to a copy of doc and returns the copy. doc itself is
+// not modified. If an operation fails, including a failed test, the error is returned.
+func ApplyJSONPatch(doc interface{}, ops []PatchOperation) (interface{}, error) {
+	result := deepCopy(doc)
+	var err error
+	for _, op := range ops {
+		switch op.Op {
+		case PatchAdd:
+			result, err = patchAdd(result, op.Path, deepCopy(op.Value))
+		case PatchRemove:
+			result, err = patchRemove(result, op.Path)
+		case PatchReplace:
+			if _, err = GetByPointer(result, op.Path); err == nil {
+				result, err = SetByPointer(result, op.Path, deepCopy(op.Value))
+			}
+		case PatchMove, PatchCopy:
+			var v interface{}
+			if v, err = GetByPointer(result, op.From); err != nil {
+				break
+			}
+			if op.Op == PatchMove {
+				if result, err = patchRemove(result, op.From); err != nil {
+					break
+				}
+			} else {
+				v = deepCopy(v)
+			}
+			result, err = patchAdd(result, op.Path, v)
+		case PatchTest:
+			var v interface{}
+			if v, err = GetByPointer(result, op.Path); err == nil && !jsonEqual(v, op.Value) {
+				err = NewError(ErrExpect, fmt.Sprintf("json patch test failed at %s", op.Path))
+			}
+		default:
+			err = NewError(ErrInvalid, fmt.Sprintf("unknown json patch operation %s", op.Op))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// CreateMergePatch returns the JSON Merge Patch (RFC 7386) that turns original into modified. Removed
+// fields are set to null. Merge patches can't set a field to null or patch part of an array, in those
+// cases use CreateJSONPatch.
+func CreateMergePatch(original interface{}, modified interface{}) interface{} {
+	om, oIsMap := original.(map[string]interface{})
+	mm, mIsMap := modified.(map[string]interface{})
+	if !oIsMap || !mIsMap {
+		return modified
+	}
+	patch := make(map[string]interface{})
+	for k := range om {
+		if _, ok := mm[k]; !ok {
+			patch[k] = nil
+		}
+	}
+	for k, mv := range mm {
+		ov, ok := om[k]
+		if !ok {
+			patch[k] = mv
+			continue
+		}
+		_, ovIsMap := ov.(map[string]interface{})
+		_, mvIsMap := mv.(map[string]interface{})
+		if ovIsMap && mvIsMap {
+			if sub := CreateMergePatch(ov, mv).(map[string]interface{}); len(sub) > 0 {
+				patch[k] = sub
+			}
+		} else if !jsonEqual(ov, mv) {
+			patch[k] = mv
+		}
+	}
+	return patch
+}
+
+// ApplyMergePatch applies a JSON Merge Patch to a copy of doc and returns the copy.
+func ApplyMergePatch(doc interface{}, patch interface{}) interface{} {
+	pm, ok := patch.(map[string]interface{})
+	if !ok {
+		return deepCopy(patch)
+	}
+	dm, ok := doc.(map[string]interface{})
+	if ok {
+		dm = deepCopy(dm).(map[string]interface{})
+	} else {
+		dm = make(map[string]interface{})
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(dm, k)
+		} else {
+			dm[k] = ApplyMergePatch(dm[k], v)
+		}
+	}
+	return dm
+}
@@ -0,0 +1,142 @@
+package api_util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSONPatchRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		modified string
+	}{
+		{"equal", `{"a":1}`, `{"a":1}`},
+		{"change field", `{"a":1,"b":"x"}`, `{"a":2,"b":"x"}`},
+		{"add and remove fields", `{"a":1,"b":{"c":true}}`, `{"b":{"d":null},"e":[1]}`},
+		{"array grows", `{"a":[1,2]}`, `{"a":[1,2,3,4]}`},
+		{"array shrinks", `{"a":[1,2,3,4]}`, `{"a":[2]}`},
+		{"array to empty", `{"a":[1,2]}`, `{"a":[]}`},
+		{"nested arrays", `[[1],[2,3]]`, `[[1,5],[],[6]]`},
+		{"escaped keys", `{"a/b":1,"c~d":{"e~1f":2}}`, `{"a/b":3,"c~d":{"e~1f":4,"~0":5}}`},
+		{"type change", `{"a":{"b":1}}`, `{"a":[1]}`},
+		{"root replaced", `1`, `"x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original, modified := decodeJson(t, tt.original), decodeJson(t, tt.modified)
+			ops := CreateJSONPatch(original, modified)
+			got, err := ApplyJSONPatch(original, ops)
+			if err != nil {
+				t.Fatalf("ApplyJSONPatch(%v) returned %v", ops, err)
+			}
+			if !reflect.DeepEqual(got, modified) {
+				t.Errorf("round trip with %v gave %v, want %v", ops, got, modified)
+			}
+			if !reflect.DeepEqual(original, decodeJson(t, tt.original)) {
+				t.Errorf("ApplyJSONPatch modified the original document: %v", original)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		ops  []PatchOperation
+		want string
+	}{
+		{"add in the middle", `{"a":[1,2]}`, []PatchOperation{{Op: PatchAdd, Path: "/a/1", Value: 9.0}}, `{"a":[1,9,2]}`},
+		{"add at the end", `{"a":[1,2]}`, []PatchOperation{{Op: PatchAdd, Path: "/a/2", Value: 9.0}}, `{"a":[1,2,9]}`},
+		{"append with dash", `{"a":[1]}`, []PatchOperation{{Op: PatchAdd, Path: "/a/-", Value: 2.0}}, `{"a":[1,2]}`},
+		{"move", `{"a":{"b":1},"c":{}}`, []PatchOperation{{Op: PatchMove, From: "/a/b", Path: "/c/d"}}, `{"a":{},"c":{"d":1}}`},
+		{"move in array", `{"a":[1,2,3]}`, []PatchOperation{{Op: PatchMove, From: "/a/0", Path: "/a/-"}}, `{"a":[2,3,1]}`},
+		{"copy", `{"a":{"b":[1]}}`, []PatchOperation{{Op: PatchCopy, From: "/a/b", Path: "/c"}}, `{"a":{"b":[1]},"c":[1]}`},
+		{"test then replace", `{"a":1}`, []PatchOperation{{Op: PatchTest, Path: "/a", Value: 1}, {Op: PatchReplace, Path: "/a", Value: 2.0}}, `{"a":2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyJSONPatch(decodeJson(t, tt.doc), tt.ops)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := decodeJson(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		op   PatchOperation
+	}{
+		{"add leading zero index", `{"a":[1,2]}`, PatchOperation{Op: PatchAdd, Path: "/a/01", Value: 9.0}},
+		{"add signed index", `{"a":[1,2]}`, PatchOperation{Op: PatchAdd, Path: "/a/+1", Value: 9.0}},
+		{"add past the end", `{"a":[1,2]}`, PatchOperation{Op: PatchAdd, Path: "/a/3", Value: 9.0}},
+		{"add to missing parent", `{}`, PatchOperation{Op: PatchAdd, Path: "/a/b", Value: 1.0}},
+		{"remove missing", `{"a":1}`, PatchOperation{Op: PatchRemove, Path: "/b"}},
+		{"replace missing", `{"a":1}`, PatchOperation{Op: PatchReplace, Path: "/b", Value: 1.0}},
+		{"move from missing", `{"a":1}`, PatchOperation{Op: PatchMove, From: "/b", Path: "/c"}},
+		{"move to missing parent", `{"a":1}`, PatchOperation{Op: PatchMove, From: "/a", Path: "/b/c"}},
+		{"copy from missing", `{"a":1}`, PatchOperation{Op: PatchCopy, From: "/b", Path: "/c"}},
+		{"test different value", `{"a":1}`, PatchOperation{Op: PatchTest, Path: "/a", Value: 2.0}},
+		{"test missing", `{"a":1}`, PatchOperation{Op: PatchTest, Path: "/b", Value: 1.0}},
+		{"unknown op", `{"a":1}`, PatchOperation{Op: "bogus", Path: "/a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := decodeJson(t, tt.doc)
+			if got, err := ApplyJSONPatch(doc, []PatchOperation{tt.op}); err == nil {
+				t.Errorf("expected an error, got %v", got)
+			}
+			if !reflect.DeepEqual(doc, decodeJson(t, tt.doc)) {
+				t.Errorf("a failed patch modified the document: %v", doc)
+			}
+		})
+	}
+}
+
+func TestMergePatchRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		modified string
+	}{
+		{"change field", `{"a":1,"b":"x"}`, `{"a":2,"b":"x"}`},
+		{"remove field", `{"a":1,"b":{"c":1,"d":2}}`, `{"b":{"d":2}}`},
+		{"add nested", `{"a":{}}`, `{"a":{"b":{"c":[1]}}}`},
+		{"arrays are replaced", `{"a":[1,2,3]}`, `{"a":[3]}`},
+		{"not an object", `{"a":1}`, `[1]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original, modified := decodeJson(t, tt.original), decodeJson(t, tt.modified)
+			patch := CreateMergePatch(original, modified)
+			if got := ApplyMergePatch(original, patch); !reflect.DeepEqual(got, modified) {
+				t.Errorf("round trip with %v gave %v, want %v", patch, got, modified)
+			}
+		})
+	}
+}
+
+// A merge patch can't set a field to null: null means remove the field.
+func TestMergePatchNull(t *testing.T) {
+	original, modified := decodeJson(t, `{"a":1}`), decodeJson(t, `{"a":null}`)
+	patch := CreateMergePatch(original, modified)
+	if want := map[string]interface{}{"a": nil}; !reflect.DeepEqual(patch, want) {
+		t.Fatalf("CreateMergePatch = %v, want %v", patch, want)
+	}
+	if got, want := ApplyMergePatch(original, patch), map[string]interface{}{}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ApplyMergePatch = %v, want %v", got, want)
+	}
+
+	// A JSON Patch keeps the null
+	got, err := ApplyJSONPatch(original, CreateJSONPatch(original, modified))
+	if err != nil || !reflect.DeepEqual(got, modified) {
+		t.Errorf("ApplyJSONPatch = %v, %v, want %v", got, err, modified)
+	}
+}
@@ -0,0 +1,129 @@
+package api_util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The functions in this file implement JSON Pointer (RFC 6901) over decoded json values, i.e.
+// map[string]interface{}, []interface{} and primitives.
+
+// ParsePointer splits a json pointer into its unescaped reference tokens. The empty pointer refers
+// to the whole document and has no tokens.
+func ParsePointer(pointer string) ([]string, error) {
+	if len(pointer) == 0 {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, NewError(ErrInvalid, fmt.Sprintf("json pointer must start with /: %s", pointer))
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// EscapePointerToken escapes a field name so it can be used as a json pointer token.
+func EscapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// MakePointer builds a json pointer from unescaped tokens.
+func MakePointer(tokens []string) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString("/")
+		b.WriteString(EscapePointerToken(t))
+	}
+	return b.String()
+}
+
+// arrayIndex converts a pointer token to an index into an array of the given length. RFC 6901 only
+// allows plain digits without leading zeros, so "+1" and "01" are rejected.
+func arrayIndex(token string, length int, pointer string) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || strings.Trim(token, "0123456789") != "" || i >= length || (len(token) > 1 && token[0] == '0') {
+		return 0, NewError(ErrNotFound, fmt.Sprintf("invalid array index %s in json pointer %s", token, pointer))
+	}
+	return i, nil
+}
+
+// GetByPointer returns the value the json pointer refers to in doc.
+func GetByPointer(doc interface{}, pointer string) (interface{}, error) {
+	tokens, err := ParsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	current := doc
+	for _, t := range tokens {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			v, ok := node[t]
+			if !ok {
+				return nil, NewError(ErrNotFound, fmt.Sprintf("field %s not found for json pointer %s", t, pointer))
+			}
+			current = v
+		case []interface{}:
+			i, err := arrayIndex(t, len(node), pointer)
+			if err != nil {
+				return nil, err
+			}
+			current = node[i]
+		default:
+			return nil, NewError(ErrNotFound, fmt.Sprintf("json pointer %s goes past a leaf value at %s", pointer, t))
+		}
+	}
+	return current, nil
+}
+
+// SetByPointer sets the value the json pointer refers to, and returns the updated document. The
+// parent of the target must exist. An existing field or array entry is replaced, a missing field is
+// added, and the token "-" appends to an array. Maps are updated in place, but arrays that grow are
+// reallocated, so always use the returned document.
+func SetByPointer(doc interface{}, pointer string, value interface{}) (interface{}, error) {
+	tokens, err := ParsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	return setAt(doc, tokens, value, pointer)
+}
+
+func setAt(node interface{}, tokens []string, value interface{}, pointer string) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	t := tokens[0]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[t]
+		if !ok && len(tokens) > 1 {
+			return nil, NewError(ErrNotFound, fmt.Sprintf("field %s not found for json pointer %s", t, pointer))
+		}
+		newChild, err := setAt(child, tokens[1:], value, pointer)
+		if err != nil {
+			return nil, err
+		}
+		n[t] = newChild
+		return n, nil
+	case []interface{}:
+		if t == "-" {
+			if len(tokens) > 1 {
+				return nil, NewError(ErrInvalid, fmt.Sprintf("- must be the last token of json pointer %s", pointer))
+			}
+			return append(n, value), nil
+		}
+		i, err := arrayIndex(t, len(n), pointer)
+		if err != nil {
+			return nil, err
+		}
+		newChild, err := setAt(n[i], tokens[1:], value, pointer)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = newChild
+		return n, nil
+	}
+	return nil, NewError(ErrNotFound, fmt.Sprintf("json pointer %s goes past a leaf value at %s", pointer, t))
+}
@@ -0,0 +1,111 @@
+package api_util
+
+import (
+	"reflect"
+	"testing"
+)
+
+const pointerDoc = `{"a/b":1,"m~n":2,"arr":[10,20,{"x":"y"}],"nested":{"k":{"deep":true}},"":"empty"}`
+
+func TestParsePointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"/", []string{""}, false},
+		{"/a~1b", []string{"a/b"}, false},
+		{"/m~0n", []string{"m~n"}, false},
+		{"/~01", []string{"~1"}, false}, // ~0 is unescaped last, so ~01 is "~1", not "/"
+		{"/a/b/0", []string{"a", "b", "0"}, false},
+		{"a", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePointer(tt.pointer)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePointer(%q) = %q, %v", tt.pointer, got, err)
+		}
+	}
+}
+
+func TestMakePointer(t *testing.T) {
+	tokens := []string{"a/b", "m~n", "0"}
+	pointer := MakePointer(tokens)
+	if pointer != "/a~1b/m~0n/0" {
+		t.Errorf("MakePointer = %s", pointer)
+	}
+	parsed, _ := ParsePointer(pointer)
+	if !reflect.DeepEqual(parsed, tokens) {
+		t.Errorf("round trip gave %q", parsed)
+	}
+}
+
+func TestGetByPointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    string
+		wantErr bool
+	}{
+		{"", pointerDoc, false},
+		{"/a~1b", `1`, false},
+		{"/m~0n", `2`, false},
+		{"/", `"empty"`, false},
+		{"/arr/0", `10`, false},
+		{"/arr/2/x", `"y"`, false},
+		{"/nested/k/deep", `true`, false},
+		{"/missing", ``, true},
+		{"/arr/3", ``, true},
+		{"/arr/-1", ``, true},
+		{"/arr/01", ``, true},
+		{"/arr/+1", ``, true},
+		{"/arr/-", ``, true},
+		{"/arr/x", ``, true},
+		{"/a~1b/c", ``, true},
+	}
+	doc := decodeJson(t, pointerDoc)
+	for _, tt := range tests {
+		got, err := GetByPointer(doc, tt.pointer)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("GetByPointer(%q) = %v, want an error", tt.pointer, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, decodeJson(t, tt.want)) {
+			t.Errorf("GetByPointer(%q) = %v, %v, want %s", tt.pointer, got, err, tt.want)
+		}
+	}
+}
+
+func TestSetByPointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"/a", `2`, `{"a":2,"arr":[1,2]}`, false},
+		{"/new", `"v"`, `{"a":1,"arr":[1,2],"new":"v"}`, false},
+		{"/arr/0", `5`, `{"a":1,"arr":[5,2]}`, false},
+		{"/arr/-", `3`, `{"a":1,"arr":[1,2,3]}`, false},
+		{"", `[]`, `[]`, false},
+		{"/arr/2", `3`, ``, true},
+		{"/arr/-/x", `3`, ``, true},
+		{"/missing/x", `3`, ``, true},
+		{"/a/x", `3`, ``, true},
+	}
+	for _, tt := range tests {
+		doc := decodeJson(t, `{"a":1,"arr":[1,2]}`)
+		got, err := SetByPointer(doc, tt.pointer, decodeJson(t, tt.value))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("SetByPointer(%q) = %v, want an error", tt.pointer, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, decodeJson(t, tt.want)) {
+			t.Errorf("SetByPointer(%q) = %v, %v, want %s", tt.pointer, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package api_util
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Swagger 2 collectionFormat values
+const (
+	CollectionCSV   = "csv"
+	CollectionSSV   = "ssv"
+	CollectionTSV   = "tsv"
+	CollectionPipes = "pipes"
+	CollectionMulti = "multi"
+)
+
+// OAS3 query parameter styles. StyleTabDelimited has no OAS3 equivalent, it only exists to
+// support the tsv collectionFormat.
+const (
+	StyleForm           = "form"
+	StyleSpaceDelimited = "spaceDelimited"
+	StylePipeDelimited  = "pipeDelimited"
+	StyleDeepObject     = "deepObject"
+	StyleTabDelimited   = "tabDelimited"
+)
+
+// ParamStyle describes how an array or object valued query parameter is serialized.
+type ParamStyle struct {
+	Style   string
+	Explode bool
+}
+
+// CollectionFormatToStyle returns the style equivalent to a Swagger 2 collectionFormat. An empty
+// format is csv, the Swagger 2 default.
+func CollectionFormatToStyle(format string) ParamStyle {
+	switch format {
+	case CollectionSSV:
+		return ParamStyle{StyleSpaceDelimited, false}
+	case CollectionTSV:
+		return ParamStyle{StyleTabDelimited, false}
+	case CollectionPipes:
+		return ParamStyle{StylePipeDelimited, false}
+	case CollectionMulti:
+		return ParamStyle{StyleForm, true}
+	default:
+		return ParamStyle{StyleForm, false}
+	}
+}
+
+// delimiter returns the separator used between the entries of a non exploded value.
+func (s ParamStyle) delimiter() string {
+	switch s.Style {
+	case StyleSpaceDelimited:
+		return " "
+	case StylePipeDelimited:
+		return "|"
+	case StyleTabDelimited:
+		return "\t"
+	default:
+		return ","
+	}
+}
+
+// sortedKeys returns the keys of the map in order, so the serialization is deterministic.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SerializeQueryParam adds the query parameter to values, serialized according to the style.
+//   - primitives are always name=value
+//   - arrays are joined with the style's delimiter (name=a,b), or repeated (name=a&name=b) when exploded
+//   - objects are flattened to name=k1,v1,k2,v2, spread into k1=v1&k2=v2 when exploded, or
+//     written as name[k1]=v1 for deepObject, which recurses into nested objects
+func SerializeQueryParam(values url.Values, name string, value interface{}, style ParamStyle) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		if style.Style == StyleDeepObject {
+			return NewError(ErrInvalid, fmt.Sprintf("deepObject style can't serialize the array parameter %s", name))
+		}
+		var entries []string
+		for _, entry := range v {
+			str, err := queryScalar(name, entry)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, str)
+		}
+		if style.Explode {
+			for _, entry := range entries {
+				values.Add(name, entry)
+			}
+		} else if len(entries) > 0 {
+			values.Add(name, strings.Join(entries, style.delimiter()))
+		}
+		return nil
+	case map[string]interface{}:
+		if style.Style == StyleDeepObject {
+			return serializeDeepObject(values, name, v)
+		}
+		var entries []string
+		for _, k := range sortedKeys(v) {
+			str, err := queryScalar(name, v[k])
+			if err != nil {
+				return err
+			}
+			if style.Explode {
+				values.Add(k, str)
+			} else {
+				entries = append(entries, k, str)
+			}
+		}
+		if !style.Explode && len(entries) > 0 {
+			values.Add(name, strings.Join(entries, style.delimiter()))
+		}
+		return nil
+	}
+	str, err := queryScalar(name, value)
+	if err != nil {
+		return err
+	}
+	values.Add(name, str)
+	return nil
+}
+
+func serializeDeepObject(values url.Values, prefix string, m map[string]interface{}) error {
+	for _, k := range sortedKeys(m) {
+		key := prefix + "[" + k + "]"
+		switch v := m[k].(type) {
+		case map[string]interface{}:
+			err := serializeDeepObject(values, key, v)
+			if err != nil {
+				return err
+			}
+		case []interface{}:
+			for _, entry := range v {
+				str, err := queryScalar(key, entry)
+				if err != nil {
+					return err
+				}
+				values.Add(key, str)
+			}
+		default:
+			str, err := queryScalar(key, v)
+			if err != nil {
+				return err
+			}
+			values.Add(key, str)
+		}
+	}
+	return nil
+}
+
+// queryScalar converts a primitive to its query string form.
+func queryScalar(name string, value interface{}) (string, error) {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return "", NewError(ErrInvalid, fmt.Sprintf("query parameter %s has a nested value that its style can't serialize", name))
+	}
+	str, err := InterfaceToJsonString(value)
+	if err != nil {
+		return "", NewError(ErrInvalid, fmt.Sprintf("query parameter %s can't be serialized: %s", name, err.Error()))
+	}
+	return str, nil
+}
+
+// MapInterfaceToQueryValues serializes all the query parameters in src. styles holds the style of
+// each parameter; parameters without an entry use the Swagger 2 default, csv.
+func MapInterfaceToQueryValues(src map[string]interface{}, styles map[string]ParamStyle) (url.Values, error) {
+	values := make(url.Values)
+	for _, k := range sortedKeys(src) {
+		style, ok := styles[k]
+		if !ok {
+			style = CollectionFormatToStyle(CollectionCSV)
+		}
+		err := SerializeQueryParam(values, k, src[k], style)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,88 @@
+package api_util
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSerializeQueryParam(t *testing.T) {
+	array := []interface{}{"a", "b", 3}
+	object := map[string]interface{}{"role": "admin", "first": "Alex"}
+	nested := map[string]interface{}{
+		"name": "x",
+		"addr": map[string]interface{}{"city": "Paris", "zip": 75001},
+		"tags": []interface{}{"t1", "t2"},
+	}
+	tests := []struct {
+		name  string
+		value interface{}
+		style ParamStyle
+		want  string
+	}{
+		{"primitive", 5, CollectionFormatToStyle(CollectionCSV), "p=5"},
+		{"string primitive", "a b", ParamStyle{StyleForm, true}, "p=a+b"},
+		{"nil is omitted", nil, ParamStyle{StyleForm, false}, ""},
+		{"empty array is omitted", []interface{}{}, ParamStyle{StyleForm, false}, ""},
+
+		{"csv", array, CollectionFormatToStyle(CollectionCSV), "p=a%2Cb%2C3"},
+		{"default collection format is csv", array, CollectionFormatToStyle(""), "p=a%2Cb%2C3"},
+		{"ssv", array, CollectionFormatToStyle(CollectionSSV), "p=a+b+3"},
+		{"tsv", array, CollectionFormatToStyle(CollectionTSV), "p=a%09b%093"},
+		{"pipes", array, CollectionFormatToStyle(CollectionPipes), "p=a%7Cb%7C3"},
+		{"multi", array, CollectionFormatToStyle(CollectionMulti), "p=a&p=b&p=3"},
+
+		{"form array", array, ParamStyle{StyleForm, false}, "p=a%2Cb%2C3"},
+		{"form array exploded", array, ParamStyle{StyleForm, true}, "p=a&p=b&p=3"},
+		{"form object", object, ParamStyle{StyleForm, false}, "p=first%2CAlex%2Crole%2Cadmin"},
+		{"form object exploded", object, ParamStyle{StyleForm, true}, "first=Alex&role=admin"},
+		{"spaceDelimited", array, ParamStyle{StyleSpaceDelimited, false}, "p=a+b+3"},
+		{"spaceDelimited exploded", array, ParamStyle{StyleSpaceDelimited, true}, "p=a&p=b&p=3"},
+		{"pipeDelimited", array, ParamStyle{StylePipeDelimited, false}, "p=a%7Cb%7C3"},
+		{"pipeDelimited exploded", array, ParamStyle{StylePipeDelimited, true}, "p=a&p=b&p=3"},
+
+		{"deepObject", object, ParamStyle{StyleDeepObject, true}, "p%5Bfirst%5D=Alex&p%5Brole%5D=admin"},
+		{"deepObject nested", nested, ParamStyle{StyleDeepObject, true},
+			"p%5Baddr%5D%5Bcity%5D=Paris&p%5Baddr%5D%5Bzip%5D=75001&p%5Bname%5D=x&p%5Btags%5D=t1&p%5Btags%5D=t2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := make(url.Values)
+			if err := SerializeQueryParam(values, "p", tt.value, tt.style); err != nil {
+				t.Fatalf("SerializeQueryParam returned %v", err)
+			}
+			if got := values.Encode(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSerializeQueryParamErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		style ParamStyle
+	}{
+		{"deepObject array", []interface{}{1, 2}, ParamStyle{StyleDeepObject, true}},
+		{"nested array in form", []interface{}{[]interface{}{1}}, ParamStyle{StyleForm, false}},
+		{"nested object in form", map[string]interface{}{"a": map[string]interface{}{"b": 1}}, ParamStyle{StyleForm, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SerializeQueryParam(make(url.Values), "p", tt.value, tt.style); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestMapInterfaceToQueryValues(t *testing.T) {
+	src := map[string]interface{}{"ids": []interface{}{1, 2}, "q": "x", "tags": []interface{}{"a", "b"}}
+	values, err := MapInterfaceToQueryValues(src, map[string]ParamStyle{"tags": CollectionFormatToStyle(CollectionMulti)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := values.Encode(), "ids=1%2C2&q=x&tags=a&tags=b"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
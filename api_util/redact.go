@@ -0,0 +1,139 @@
+package api_util
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RedactedValue replaces the values of sensitive fields.
+const RedactedValue = "******"
+
+// DefaultRedactPatterns are the field name patterns masked unless configured otherwise.
+var DefaultRedactPatterns = []string{"password", "passwd", "secret", "token", "authorization", "cookie", "api[-_]?key", "ssn"}
+
+var redactRegexps = mustCompileRedactPatterns(DefaultRedactPatterns)
+
+func mustCompileRedactPatterns(patterns []string) []*regexp.Regexp {
+	res, err := compileRedactPatterns(patterns)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// Patterns are matched against whole words of the field name, see fieldWords.
+func compileRedactPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile("(?i)(^|_)(?:" + p + ")($|_)")
+		if err != nil {
+			return nil, NewError(ErrInvalid, "invalid redact pattern "+p+": "+err.Error())
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// SetRedactPatterns replaces the patterns used to find sensitive fields. The patterns are regular
+// expressions matched case insensitively against whole words of the field name: "secret" matches
+// client_secret and clientSecret, but not secretary. An empty list turns redaction off.
+func SetRedactPatterns(patterns []string) error {
+	res, err := compileRedactPatterns(patterns)
+	if err != nil {
+		return err
+	}
+	redactRegexps = res
+	return nil
+}
+
+var (
+	camelLowerUpper = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+	camelAcronym    = regexp.MustCompile(`([A-Z]+)([A-Z][a-z])`)
+	nonAlphanumeric = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// fieldWords splits a field name into its words, joined by "_": "X-Api-Key", "apiKey", "APIKey" and
+// "api_key" all become "api_key" (up to case).
+func fieldWords(name string) string {
+	name = camelLowerUpper.ReplaceAllString(name, "${1}_${2}")
+	name = camelAcronym.ReplaceAllString(name, "${1}_${2}")
+	return strings.Trim(nonAlphanumeric.ReplaceAllString(name, "_"), "_")
+}
+
+// IsSensitiveField returns whether values of the named field should be masked.
+func IsSensitiveField(name string) bool {
+	words := fieldWords(name)
+	for _, re := range redactRegexps {
+		if re.MatchString(words) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of the input where the values of all the sensitive fields, at any depth,
+// are replaced by RedactedValue. The input is not modified. The values of authorization fields keep
+// their scheme, see RedactString.
+func Redact(in interface{}) interface{} {
+	switch v := in.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		out := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			if IsSensitiveField(k) && val != nil {
+				out[k] = redactField(k, val)
+			} else {
+				out[k] = Redact(val)
+			}
+		}
+		return out
+	case map[string]string:
+		if v == nil {
+			return v
+		}
+		out := make(map[string]string, len(v))
+		for k, val := range v {
+			if IsSensitiveField(k) {
+				out[k] = redactField(k, val).(string)
+			} else {
+				out[k] = val
+			}
+		}
+		return out
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			out[i] = Redact(val)
+		}
+		return out
+	}
+	return in
+}
+
+// redactField returns the masked value of a sensitive field.
+func redactField(name string, value interface{}) interface{} {
+	if str, ok := value.(string); ok && strings.Contains(strings.ToLower(name), "authorization") {
+		return RedactString(str)
+	}
+	return RedactedValue
+}
+
+var authSchemes = []string{"bearer ", "basic ", "digest "}
+
+// RedactString masks an authorization header value, keeping its scheme so that logs still tell which
+// scheme was used: "Bearer xyz" becomes "Bearer ******". Values without a known scheme are masked
+// entirely. Only use it on authorization values, other strings may legitimately start with "basic ".
+func RedactString(s string) string {
+	lower := strings.ToLower(s)
+	for _, scheme := range authSchemes {
+		if strings.HasPrefix(lower, scheme) && len(s) > len(scheme) {
+			return s[:len(scheme)] + RedactedValue
+		}
+	}
+	return RedactedValue
+}
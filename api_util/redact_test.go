@@ -0,0 +1,96 @@
+package api_util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsSensitiveField(t *testing.T) {
+	tests := map[string]bool{
+		"password":            true,
+		"newPassword":         true,
+		"password_hash":       true,
+		"client_secret":       true,
+		"clientSecret":        true,
+		"access_token":        true,
+		"refreshToken":        true,
+		"X-Auth-Token":        true,
+		"Authorization":       true,
+		"Proxy-Authorization": true,
+		"Set-Cookie":          true,
+		"apiKey":              true,
+		"APIKey":              true,
+		"x-api-key":           true,
+		"apikey":              true,
+		"ssn":                 true,
+		"customer_ssn":        true,
+		"className":           false,
+		"secretary":           false,
+		"tokenizer":           false,
+		"passwordless":        false,
+		"name":                false,
+		"keyboard":            false,
+	}
+	for name, want := range tests {
+		if got := IsSensitiveField(name); got != want {
+			t.Errorf("IsSensitiveField(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	in := map[string]interface{}{
+		"user":          "alex",
+		"password":      "hunter2",
+		"className":     "Basic plan with extras",
+		"Authorization": "Bearer abc.def",
+		"nested":        []interface{}{map[string]interface{}{"token": 5, "note": "basic text"}},
+		"headers":       map[string]string{"Authorization": "Basic dXNlcjpwYXNz", "Accept": "basic/json"},
+		"empty":         nil,
+		"secret":        nil,
+	}
+	want := map[string]interface{}{
+		"user":          "alex",
+		"password":      RedactedValue,
+		"className":     "Basic plan with extras",
+		"Authorization": "Bearer " + RedactedValue,
+		"nested":        []interface{}{map[string]interface{}{"token": RedactedValue, "note": "basic text"}},
+		"headers":       map[string]string{"Authorization": "Basic " + RedactedValue, "Accept": "basic/json"},
+		"empty":         nil,
+		"secret":        nil,
+	}
+	got := Redact(in)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Redact = %v\nwant %v", got, want)
+	}
+	if in["password"] != "hunter2" {
+		t.Error("Redact modified its input")
+	}
+}
+
+func TestRedactString(t *testing.T) {
+	tests := map[string]string{
+		"Bearer abc":          "Bearer " + RedactedValue,
+		"basic dXNlcjpwYXNz":  "basic " + RedactedValue,
+		`Digest username="a"`: "Digest " + RedactedValue,
+		"opaque-token":        RedactedValue,
+	}
+	for in, want := range tests {
+		if got := RedactString(in); got != want {
+			t.Errorf("RedactString(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSetRedactPatterns(t *testing.T) {
+	defer SetRedactPatterns(DefaultRedactPatterns)
+	if err := SetRedactPatterns([]string{"pin"}); err != nil {
+		t.Fatal(err)
+	}
+	if !IsSensitiveField("cardPin") || IsSensitiveField("password") || IsSensitiveField("spinner") {
+		t.Error("custom patterns not applied")
+	}
+	if err := SetRedactPatterns([]string{"("}); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}
@@ -0,0 +1,111 @@
+package api_util
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pseudo layouts for unix epoch times. Epoch values are told apart by magnitude: values below 1e11
+// are seconds (up to year 5138), bigger values are milliseconds.
+const (
+	LayoutUnixSeconds = "unix"
+	LayoutUnixMillis  = "unixmilli"
+)
+
+// DefaultTimeLayouts are the layouts TimeCompare tries unless configured otherwise.
+var DefaultTimeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC1123,
+	time.RFC1123Z,
+	time.RFC850,
+	time.ANSIC,
+	"2006-01-02T15:04:05", // RFC3339 without a zone, taken as UTC
+	"2006-01-02",
+	LayoutUnixSeconds,
+	LayoutUnixMillis,
+}
+
+// TimeLayouts are tried in order when parsing a time, see SetTimeLayouts.
+var TimeLayouts = DefaultTimeLayouts
+
+// TimeTolerance is the largest difference between two instants that TimeCompare accepts as equal.
+var TimeTolerance time.Duration
+
+// CompareEpochTimes lets TimeCompare match an epoch number against a formatted time. It is off by
+// default because any small number, or numeric string, would then equal some time in 1970.
+var CompareEpochTimes bool
+
+// DateMatchesDateTime lets TimeCompare match a date without a time of day against a date time, taking
+// the date as midnight UTC. It is off by default, a date and a time are different values.
+var DateMatchesDateTime bool
+
+// SetTimeLayouts replaces the layouts used to parse times. Layouts use the time package format, plus
+// LayoutUnixSeconds and LayoutUnixMillis. An empty list restores the defaults.
+func SetTimeLayouts(layouts []string) {
+	if len(layouts) == 0 {
+		TimeLayouts = DefaultTimeLayouts
+		return
+	}
+	TimeLayouts = layouts
+}
+
+// ParseTime parses the value with the first layout in TimeLayouts that accepts it. epoch tells the
+// value was an epoch number rather than a formatted time.
+func ParseTime(v interface{}) (t time.Time, epoch bool, ok bool) {
+	t, layout, ok := parseTimeLayout(v)
+	return t, isEpochLayout(layout), ok
+}
+
+func isEpochLayout(layout string) bool {
+	return layout == LayoutUnixSeconds || layout == LayoutUnixMillis
+}
+
+// isDateLayout returns whether the layout has no time of day, i.e. no minutes.
+func isDateLayout(layout string) bool {
+	return !isEpochLayout(layout) && !strings.Contains(layout, "04")
+}
+
+// parseTimeLayout is ParseTime, returning the layout that parsed the value.
+func parseTimeLayout(v interface{}) (t time.Time, layout string, ok bool) {
+	var str string
+	switch value := v.(type) {
+	case string:
+		str = value
+	case json.Number:
+		str = value.String()
+	default:
+		n, isNumber, _ := numberValue(v)
+		if !isNumber {
+			return time.Time{}, "", false
+		}
+		str = n.String()
+	}
+
+	for _, layout := range TimeLayouts {
+		switch layout {
+		case LayoutUnixSeconds, LayoutUnixMillis:
+			n, err := strconv.ParseFloat(str, 64)
+			if err != nil || math.IsInf(n, 0) || math.IsNaN(n) {
+				continue
+			}
+			isMillis := math.Abs(n) >= 1e11
+			if isMillis != (layout == LayoutUnixMillis) {
+				continue
+			}
+			if isMillis {
+				return time.UnixMilli(int64(n)).UTC(), layout, true
+			}
+			sec, frac := math.Modf(n)
+			return time.Unix(int64(sec), int64(frac*1e9)).UTC(), layout, true
+		default:
+			t, err := time.Parse(layout, str)
+			if err == nil {
+				return t, layout, true
+			}
+		}
+	}
+	return time.Time{}, "", false
+}
@@ -0,0 +1,84 @@
+package api_util
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimeCompare(t *testing.T) {
+	tests := []struct {
+		name  string
+		v1    interface{}
+		v2    interface{}
+		epoch bool // CompareEpochTimes
+		date  bool // DateMatchesDateTime
+		want  bool
+	}{
+		{"rfc3339 and rfc1123", "2022-01-01T12:00:00Z", "Sat, 01 Jan 2022 12:00:00 GMT", false, false, true},
+		{"zones", "2022-01-01T12:00:00Z", "2022-01-01T13:00:00+01:00", false, false, true},
+		{"different minutes", "2022-01-01T12:00:00Z", "2022-01-01T12:01:00Z", false, false, false},
+		{"not times", "abc", "2022-01-01T12:00:00Z", false, false, false},
+		{"two epochs are numbers", 5, "5", true, false, false},
+
+		{"epoch string off", "1", "1970-01-01T00:00:01Z", false, false, false},
+		{"epoch number off", 5, "1970-01-01T00:00:05Z", false, false, false},
+		{"epoch seconds on", int64(1641038400), "2022-01-01T12:00:00Z", true, false, true},
+		{"epoch millis on", json.Number("1641038400000"), "2022-01-01T12:00:00Z", true, false, true},
+		{"epoch wrong time on", int64(1641038401), "2022-01-01T12:00:00Z", true, false, false},
+
+		{"dates", "2022-01-01", "2022-01-01", false, false, true},
+		{"date and date time off", "2022-01-01", "2022-01-01T00:00:00Z", false, false, false},
+		{"date and date time on", "2022-01-01", "2022-01-01T00:00:00Z", false, true, true},
+	}
+	defer func(epoch, date bool) { CompareEpochTimes, DateMatchesDateTime = epoch, date }(CompareEpochTimes, DateMatchesDateTime)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			CompareEpochTimes, DateMatchesDateTime = tt.epoch, tt.date
+			if got := TimeCompare(tt.v1, tt.v2); got != tt.want {
+				t.Errorf("TimeCompare(%v, %v) = %v, want %v", tt.v1, tt.v2, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterfaceEqualsTimes(t *testing.T) {
+	for _, pair := range [][2]interface{}{
+		{"1", "1970-01-01T00:00:01Z"},
+		{5, "1970-01-01T00:00:05Z"},
+		{"2022-01-01", "2022-01-01T00:00:00Z"},
+	} {
+		if InterfaceEquals(pair[0], pair[1]) {
+			t.Errorf("InterfaceEquals(%v, %v) should be false", pair[0], pair[1])
+		}
+	}
+	if !InterfaceEquals("2022-01-01T12:00:00Z", "2022-01-01T12:00:00.000Z") {
+		t.Error("the same instant in two formats should be equal")
+	}
+}
+
+func TestTimeTolerance(t *testing.T) {
+	defer func(old time.Duration) { TimeTolerance = old }(TimeTolerance)
+	TimeTolerance = 2 * time.Second
+	if !TimeCompare("2022-01-01T12:00:00Z", "2022-01-01T12:00:02Z") {
+		t.Error("2s apart should be within the tolerance")
+	}
+	if TimeCompare("2022-01-01T12:00:00Z", "2022-01-01T12:00:03Z") {
+		t.Error("3s apart should be out of the tolerance")
+	}
+}
+
+func TestSetTimeLayouts(t *testing.T) {
+	defer SetTimeLayouts(nil)
+	SetTimeLayouts([]string{"02/01/2006 15:04"})
+	if !TimeCompare("01/02/2022 10:30", "01/02/2022 10:30") {
+		t.Error("custom layout not used")
+	}
+	if TimeCompare("2022-01-01T12:00:00Z", "2022-01-01T12:00:00Z") {
+		t.Error("layouts not in the list should not parse")
+	}
+	SetTimeLayouts(nil)
+	if !TimeCompare("2022-01-01T12:00:00Z", "Sat, 01 Jan 2022 12:00:00 GMT") {
+		t.Error("defaults not restored")
+	}
+}